@@ -1,32 +1,60 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/predlogi/models"
 	"github.com/PRPO-skupina-02/predlogi/predlogi"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // TriggerRecommendationJob godoc
 //
 //	@Summary		Manually trigger recommendation generation job
-//	@Description	Triggers the recommendation generation process for all users
+//	@Description	Starts the recommendation generation process for all users in the background. Poll the returned job run for progress.
+//	@Description	A dry run stores the recommendations with the dry_run status and does not email anyone.
 //	@Tags			admin
 //	@Security		BearerAuth
-//	@Success		202	{object}	map[string]string
-//	@Failure		401	{object}	middleware.HttpError
-//	@Failure		403	{object}	middleware.HttpError
-//	@Failure		500	{object}	middleware.HttpError
+//	@Produce		json
+//	@Param			dry_run	query		bool	false	"Generate recommendations without emailing them"	Default(false)
+//	@Success		202		{object}	JobRunResponse
+//	@Failure		400		{object}	middleware.HttpError
+//	@Failure		401		{object}	middleware.HttpError
+//	@Failure		403		{object}	middleware.HttpError
+//	@Failure		409		{object}	middleware.HttpError
+//	@Failure		500		{object}	middleware.HttpError
 //	@Router			/api/v1/predlogi/admin/trigger-job [post]
-func TriggerRecommendationJob(c *gin.Context) {
-	db := middleware.GetContextTransaction(c)
+func TriggerRecommendationJob(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The job outlives this request, so it runs on the root connection
+		// rather than the request transaction.
+		dryRun := false
+		if value := c.Query("dry_run"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				_ = c.Error(middleware.NewBadRequestError("Invalid dry_run"))
+				return
+			}
+			dryRun = parsed
+		}
 
-	// Trigger job asynchronously
-	go predlogi.RunRecommendationJob(db)
+		run, err := predlogi.StartRecommendationJob(db, models.JobTriggerAdmin, dryRun)
+		if errors.Is(err, predlogi.ErrLockHeld) {
+			_ = c.Error(&middleware.HttpError{
+				Code:    http.StatusConflict,
+				Message: "A recommendation job is already in progress",
+			})
+			return
+		}
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
 
-	c.JSON(http.StatusAccepted, gin.H{
-		"message": "Recommendation job triggered successfully",
-		"status":  "processing",
-	})
+		c.JSON(http.StatusAccepted, newJobRunResponse(*run))
+	}
 }
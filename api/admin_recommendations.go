@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AdminRecommendationResponse struct {
+	RecommendationResponse
+	UpdatedAt    time.Time `json:"updated_at"`
+	UserID       uuid.UUID `json:"user_id"`
+	EmailTo      string    `json:"email_to"`
+	EmailSubject string    `json:"email_subject"`
+	Tier         string    `json:"tier"`
+}
+
+func newAdminRecommendationResponse(recommendation models.Recommendation, movie *spored.Movie) AdminRecommendationResponse {
+	return AdminRecommendationResponse{
+		RecommendationResponse: newRecommendationResponse(recommendation, movie),
+		UpdatedAt:              recommendation.UpdatedAt,
+		UserID:                 recommendation.UserID,
+		EmailTo:                recommendation.EmailTo,
+		EmailSubject:           recommendation.EmailSubject,
+		Tier:                   recommendation.Tier,
+	}
+}
+
+type AdminRecommendationDetailResponse struct {
+	AdminRecommendationResponse
+	GenerationContext json.RawMessage `json:"generation_context" swaggertype:"object"`
+}
+
+func newAdminRecommendationDetailResponse(recommendation models.Recommendation, movie *spored.Movie) AdminRecommendationDetailResponse {
+	generationContext := json.RawMessage("null")
+	if recommendation.GenerationContext != "" {
+		generationContext = json.RawMessage(recommendation.GenerationContext)
+	}
+
+	return AdminRecommendationDetailResponse{
+		AdminRecommendationResponse: newAdminRecommendationResponse(recommendation, movie),
+		GenerationContext:           generationContext,
+	}
+}
+
+// getRecommendationFilters builds the admin list filters from the query string.
+func getRecommendationFilters(c *gin.Context) (*request.FilterOptions, error) {
+	filters := request.NewFilterOptions()
+
+	if status := c.Query("status"); status != "" {
+		if !models.RecommendationStatus(status).Valid() {
+			return nil, middleware.NewBadRequestError("Invalid status")
+		}
+		filters.AddFilter(models.EqualFilter{Column: "status", Value: status})
+	}
+
+	for _, key := range []string{"user_id", "movie_id"} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, middleware.NewBadRequestError("Invalid " + key)
+		}
+		filters.AddFilter(models.EqualFilter{Column: key, Value: id})
+	}
+
+	if emailTo := c.Query("email_to"); emailTo != "" {
+		filters.AddFilter(models.EqualFilter{Column: "LOWER(email_to)", Value: strings.ToLower(emailTo)})
+	}
+
+	createdFrom, err := getTimeQuery(c, "created_from", false)
+	if err != nil {
+		return nil, err
+	}
+	createdTo, err := getTimeQuery(c, "created_to", true)
+	if err != nil {
+		return nil, err
+	}
+	if createdFrom != nil || createdTo != nil {
+		filters.AddFilter(models.RangeFilter{Column: "created_at", Min: createdFrom, Max: createdTo})
+	}
+
+	minConfidence, err := getFloatQuery(c, "min_confidence")
+	if err != nil {
+		return nil, err
+	}
+	maxConfidence, err := getFloatQuery(c, "max_confidence")
+	if err != nil {
+		return nil, err
+	}
+	if minConfidence != nil || maxConfidence != nil {
+		filters.AddFilter(models.RangeFilter{Column: "confidence_score", Min: minConfidence, Max: maxConfidence})
+	}
+
+	return filters, nil
+}
+
+// getTimeQuery parses an RFC 3339 timestamp or a date. A date used as an upper
+// bound covers the whole day.
+func getTimeQuery(c *gin.Context, key string, endOfDay bool) (any, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, middleware.NewBadRequestError("Invalid " + key)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+func getFloatQuery(c *gin.Context, key string) (any, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, middleware.NewBadRequestError("Invalid " + key)
+	}
+	return f, nil
+}
+
+// RecommendationsList godoc
+//
+//	@Summary		List recommendations
+//	@Description	Lists recommendations across all users, newest first, with optional filters
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			limit			query		int		false	"Limit the number of responses"	Default(10)
+//	@Param			offset			query		int		false	"Offset the first response"		Default(0)
+//	@Param			sort			query		string	false	"Sort results"
+//	@Param			status			query		string	false	"Recommendation status"	Enums(pending, sent, opened, clicked, failed, dry_run)
+//	@Param			user_id			query		string	false	"User ID"				Format(uuid)
+//	@Param			movie_id		query		string	false	"Movie ID"				Format(uuid)
+//	@Param			email_to		query		string	false	"Recipient email address, case insensitive"
+//	@Param			created_from	query		string	false	"Created at or after, RFC 3339 timestamp or date"
+//	@Param			created_to		query		string	false	"Created at or before, RFC 3339 timestamp or date"
+//	@Param			min_confidence	query		number	false	"Minimum confidence score"
+//	@Param			max_confidence	query		number	false	"Maximum confidence score"
+//	@Success		200				{object}	request.PaginatedResponse{data=[]AdminRecommendationResponse}
+//	@Failure		400				{object}	middleware.HttpError
+//	@Failure		401				{object}	middleware.HttpError
+//	@Failure		403				{object}	middleware.HttpError
+//	@Failure		500				{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/recommendations [get]
+func RecommendationsList(sporedClient *spored.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx := middleware.GetContextTransaction(c)
+		pagination := request.GetNormalizedPaginationArgs(c)
+		sort := request.GetSortOptions(c)
+
+		filters, err := getRecommendationFilters(c)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		recommendations, total, err := models.GetRecommendations(tx, filters, pagination, sort)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		movies := lookupMovies(sporedClient, recommendations)
+
+		response := []AdminRecommendationResponse{}
+		for _, recommendation := range recommendations {
+			response = append(response, newAdminRecommendationResponse(recommendation, movies[recommendation.MovieID]))
+		}
+
+		request.RenderPaginatedResponse(c, response, int(total))
+	}
+}
+
+// RecommendationsShow godoc
+//
+//	@Summary		Get a recommendation
+//	@Description	Returns a single recommendation with its movie and the context it was generated from
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string	true	"Recommendation ID"	Format(uuid)
+//	@Success		200	{object}	AdminRecommendationDetailResponse
+//	@Failure		400	{object}	middleware.HttpError
+//	@Failure		401	{object}	middleware.HttpError
+//	@Failure		403	{object}	middleware.HttpError
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/recommendations/{id} [get]
+func RecommendationsShow(sporedClient *spored.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx := middleware.GetContextTransaction(c)
+
+		id, err := request.GetUUIDParam(c, "id")
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		recommendation, err := models.GetRecommendation(tx, id)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		movies := lookupMovies(sporedClient, []models.Recommendation{recommendation})
+
+		c.JSON(http.StatusOK, newAdminRecommendationDetailResponse(recommendation, movies[recommendation.MovieID]))
+	}
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"net/http"
+	"os"
 
 	"github.com/PRPO-skupina-02/common/middleware"
 	_ "github.com/PRPO-skupina-02/predlogi/api/docs"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/services"
 	"github.com/gin-gonic/gin"
 	ut "github.com/go-playground/universal-translator"
 	swaggerFiles "github.com/swaggo/files"
@@ -21,20 +24,60 @@ import (
 // @in							header
 // @name						Authorization
 // @description				Type "Bearer" followed by a space and JWT token.
-func Register(router *gin.Engine, db *gorm.DB, trans ut.Translator) {
+func Register(router *gin.Engine, db *gorm.DB, trans ut.Translator, links *services.EmailLinks, sporedClient *spored.Client) {
 	// Healthcheck
 	router.GET("/healthcheck", healthcheck)
 
 	// Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Public email tracking links, authenticated by their signed token
+	public := router.Group("/api/v1/predlogi")
+	public.Use(middleware.TransactionMiddleware(db))
+	public.Use(middleware.TranslationMiddleware(trans))
+	public.Use(middleware.ErrorMiddleware)
+	public.GET("/o/:token", TrackOpen(links))
+	public.GET("/r/:token", TrackClick(links))
+	public.GET("/f/:type/:token", TrackFeedbackConfirm(links))
+	public.POST("/f/:type/:token", TrackFeedback(links))
+	public.GET("/u/:token", UnsubscribeConfirm(links))
+	public.POST("/u/:token", Unsubscribe(links)) // RFC 8058 one-click unsubscribe
+
+	userAuth := middleware.UserMiddleware(os.Getenv("AUTH_HOST"))
+
+	// End user API
+	v1 := router.Group("/api/v1/predlogi")
+	v1.Use(middleware.TransactionMiddleware(db))
+	v1.Use(middleware.TranslationMiddleware(trans))
+	v1.Use(middleware.ErrorMiddleware)
+	v1.Use(userAuth)
+	v1.GET("/recommendations/me", MyRecommendationsList(sporedClient))
+	v1.POST("/recommendations/:id/feedback", MyRecommendationFeedback)
+	v1.GET("/preferences/me", MyPreferencesShow)
+	v1.PUT("/preferences/me", MyPreferencesUpdate)
+
 	// Admin API
 	admin := router.Group("/api/v1/predlogi/admin")
 	admin.Use(middleware.TransactionMiddleware(db))
 	admin.Use(middleware.TranslationMiddleware(trans))
 	admin.Use(middleware.ErrorMiddleware)
+	admin.Use(userAuth)
 	admin.Use(middleware.RequireAdmin())
-	admin.POST("/trigger-job", TriggerRecommendationJob)
+	admin.POST("/trigger-job", TriggerRecommendationJob(db))
+	admin.GET("/jobs", JobRunsList)
+	admin.GET("/jobs/:id", JobRunsShow)
+	admin.POST("/jobs/:id/cancel", JobRunsCancel)
+	admin.GET("/recommendations", RecommendationsList(sporedClient))
+	admin.GET("/recommendations/:id", RecommendationsShow(sporedClient))
+
+	// Long-running admin requests, kept out of the request transaction
+	adminLongRunning := router.Group("/api/v1/predlogi/admin")
+	adminLongRunning.Use(middleware.TranslationMiddleware(trans))
+	adminLongRunning.Use(middleware.ErrorMiddleware)
+	adminLongRunning.Use(userAuth)
+	adminLongRunning.Use(middleware.RequireAdmin())
+	adminLongRunning.GET("/jobs/:id/events", JobRunsEvents(db))
+	adminLongRunning.POST("/users/:id/preview", PreviewRecommendation(db))
 }
 
 func healthcheck(c *gin.Context) {
@@ -1,12 +1,18 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/PRPO-skupina-02/common/clients/auth/models"
+	"github.com/PRPO-skupina-02/common/validation"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHealthcheck(t *testing.T) {
@@ -27,3 +33,61 @@ func performRequest(r http.Handler, req *http.Request) *httptest.ResponseRecorde
 	r.ServeHTTP(w, req)
 	return w
 }
+
+// newAuthServer fakes the auth service, accepting the role of a user as their
+// token.
+func newAuthServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Token string `json:"token"`
+		}
+		if r.URL.Path != "/api/v1/auth/verify" || json.NewDecoder(r.Body).Decode(&body) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(models.APIUserResponse{
+			ID:     uuid.New().String(),
+			Email:  body.Token + "@example.com",
+			Role:   models.ModelsUserRole(body.Token),
+			Active: true,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAdminRoutesAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("AUTH_HOST", strings.TrimPrefix(newAuthServer(t).URL, "http://"))
+
+	trans, err := validation.RegisterValidation()
+	require.NoError(t, err)
+
+	router := gin.New()
+	Register(router, nil, trans, nil, nil)
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"NoToken", "", http.StatusUnauthorized},
+		{"Customer", "Bearer " + string(models.ModelsUserRoleCustomer), http.StatusForbidden},
+		// Past authentication and the admin check, the handler rejects the ID
+		{"Admin", "Bearer " + string(models.ModelsUserRoleAdmin), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/predlogi/admin/users/not-a-uuid/preview", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := performRequest(router, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
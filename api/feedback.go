@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// The confirmation form posts back to the page's own URL.
+const feedbackConfirmPage = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Feedback</title></head>` +
+	`<body><p>%s</p><form method="post"><button type="submit">Confirm</button></form></body></html>`
+
+var feedbackQuestions = map[models.FeedbackType]string{
+	models.FeedbackLike:          "Did you like this recommendation?",
+	models.FeedbackDislike:       "Did you dislike this recommendation?",
+	models.FeedbackNotInterested: "Are you not interested in this movie? We will not recommend it again.",
+}
+
+const feedbackThanksPage = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Thank you</title></head>` +
+	`<body><p>Thanks for your feedback! We will use it to improve your recommendations.</p></body></html>`
+
+type FeedbackRequest struct {
+	Type models.FeedbackType `json:"type" binding:"required,oneof=like dislike not_interested"`
+}
+
+type FeedbackResponse struct {
+	RecommendationID uuid.UUID           `json:"recommendation_id"`
+	MovieID          uuid.UUID           `json:"movie_id"`
+	Type             models.FeedbackType `json:"type"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+func newFeedbackResponse(feedback models.RecommendationFeedback) FeedbackResponse {
+	return FeedbackResponse{
+		RecommendationID: feedback.RecommendationID,
+		MovieID:          feedback.MovieID,
+		Type:             feedback.Type,
+		UpdatedAt:        feedback.UpdatedAt,
+	}
+}
+
+func saveFeedback(c *gin.Context, recommendation models.Recommendation, feedbackType models.FeedbackType) (models.RecommendationFeedback, error) {
+	tx := middleware.GetContextTransaction(c)
+
+	feedback := models.RecommendationFeedback{
+		RecommendationID: recommendation.ID,
+		UserID:           recommendation.UserID,
+		MovieID:          recommendation.MovieID,
+		Type:             feedbackType,
+	}
+
+	err := feedback.Upsert(tx)
+	return feedback, err
+}
+
+// MyRecommendationFeedback godoc
+//
+//	@Summary		Give feedback on a recommendation
+//	@Description	Records whether the authenticated user liked a recommendation. Disliked and not interested movies are not recommended again.
+//	@Tags			recommendations
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Recommendation ID"	Format(uuid)
+//	@Param			request	body		FeedbackRequest	true	"Feedback"
+//	@Success		200		{object}	FeedbackResponse
+//	@Failure		400		{object}	middleware.HttpError
+//	@Failure		401		{object}	middleware.HttpError
+//	@Failure		404		{object}	middleware.HttpError
+//	@Failure		500		{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/recommendations/{id}/feedback [post]
+func MyRecommendationFeedback(c *gin.Context) {
+	tx := middleware.GetContextTransaction(c)
+	userID := middleware.GetContextUserID(c)
+	if c.IsAborted() {
+		return
+	}
+
+	id, err := request.GetUUIDParam(c, "id")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	recommendation, err := models.GetRecommendation(tx, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// Dry run recommendations were never emailed to the user
+	if recommendation.UserID != userID || recommendation.Status == models.StatusDryRun {
+		_ = c.Error(middleware.NewNamedNotFoundError("Recommendation"))
+		return
+	}
+
+	feedback, err := saveFeedback(c, recommendation, req.Type)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newFeedbackResponse(feedback))
+}
+
+// verifyFeedbackLink returns the feedback type and recommendation ID of a
+// signed feedback link.
+func verifyFeedbackLink(c *gin.Context, links *services.EmailLinks) (models.FeedbackType, uuid.UUID, error) {
+	feedbackType := models.FeedbackType(c.Param("type"))
+	if !feedbackType.Valid() {
+		return feedbackType, uuid.Nil, middleware.NewNamedNotFoundError("Link")
+	}
+
+	id, err := links.Verify(services.FeedbackTokenPurpose(feedbackType), c.Param("token"))
+	if err != nil {
+		return feedbackType, uuid.Nil, middleware.NewNamedNotFoundError("Link")
+	}
+
+	return feedbackType, id, nil
+}
+
+// TrackFeedbackConfirm godoc
+//
+//	@Summary		Confirm recommendation feedback
+//	@Description	Shows a page asking the user to confirm the feedback of a signed email link. Nothing is recorded, so link scanners that fetch every link do not leave feedback.
+//	@Tags			tracking
+//	@Produce		html
+//	@Param			type	path	string	true	"Feedback type"	Enums(like, dislike, not_interested)
+//	@Param			token	path	string	true	"Signed feedback token"
+//	@Success		200
+//	@Failure		404	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/f/{type}/{token} [get]
+func TrackFeedbackConfirm(links *services.EmailLinks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		feedbackType, _, err := verifyFeedbackLink(c, links)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		page := fmt.Sprintf(feedbackConfirmPage, feedbackQuestions[feedbackType])
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	}
+}
+
+// TrackFeedback godoc
+//
+//	@Summary		Record recommendation feedback
+//	@Description	Records feedback from a signed email link and shows a short confirmation page
+//	@Tags			tracking
+//	@Produce		html
+//	@Param			type	path	string	true	"Feedback type"	Enums(like, dislike, not_interested)
+//	@Param			token	path	string	true	"Signed feedback token"
+//	@Success		200
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/f/{type}/{token} [post]
+func TrackFeedback(links *services.EmailLinks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		feedbackType, id, err := verifyFeedbackLink(c, links)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		tx := middleware.GetContextTransaction(c)
+
+		recommendation, err := models.GetRecommendation(tx, id)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		// Links in a stored dry run email were never sent to the user
+		if recommendation.Status == models.StatusDryRun {
+			_ = c.Error(middleware.NewNamedNotFoundError("Link"))
+			return
+		}
+
+		if _, err := saveFeedback(c, recommendation, feedbackType); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(feedbackThanksPage))
+	}
+}
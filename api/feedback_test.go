@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackFeedbackConfirmDoesNotRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PUBLIC_BASE_URL", "https://predlogi.example.com")
+	t.Setenv("RECOMMENDATION_TOKEN_SECRET", "secret")
+
+	links, err := services.NewEmailLinksFromEnv()
+	require.NoError(t, err)
+
+	// No transaction middleware, the confirmation page must not touch the
+	// database
+	router := gin.New()
+	router.GET("/api/v1/predlogi/f/:type/:token", TrackFeedbackConfirm(links))
+
+	link := links.FeedbackURL(uuid.New(), models.FeedbackNotInterested)
+	req, _ := http.NewRequest("GET", strings.TrimPrefix(link, "https://predlogi.example.com"), nil)
+	w := performRequest(router, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "not interested")
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+}
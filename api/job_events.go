@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/predlogi"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	jobEventsPollInterval = time.Second
+	jobEventsBatchSize    = 500
+)
+
+type JobRunEventResponse struct {
+	ID         int64      `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Type       string     `json:"type"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	UserIndex  int        `json:"user_index,omitempty"`
+	TotalUsers int        `json:"total_users,omitempty"`
+	MovieID    *uuid.UUID `json:"movie_id,omitempty"`
+	MovieTitle string     `json:"movie_title,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+func newJobRunEventResponse(event models.JobRunEvent) JobRunEventResponse {
+	return JobRunEventResponse{
+		ID:         event.ID,
+		CreatedAt:  event.CreatedAt,
+		Type:       event.Type,
+		UserID:     event.UserID,
+		UserIndex:  event.UserIndex,
+		TotalUsers: event.TotalUsers,
+		MovieID:    event.MovieID,
+		MovieTitle: event.MovieTitle,
+		Message:    event.Message,
+	}
+}
+
+// JobRunsEvents godoc
+//
+//	@Summary		Stream progress of a recommendation job run
+//	@Description	Streams per-user progress events of a job run as Server-Sent Events. Each event carries the event ID, so clients can resume with the Last-Event-ID header. A final "finished" event carries the job run once it is no longer running.
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		text/event-stream
+//	@Param			id				path		string	true	"Job run ID"	Format(uuid)
+//	@Param			Last-Event-ID	header		int		false	"Resume after this event ID"
+//	@Success		200				{object}	JobRunEventResponse
+//	@Failure		400				{object}	middleware.HttpError
+//	@Failure		401				{object}	middleware.HttpError
+//	@Failure		403				{object}	middleware.HttpError
+//	@Failure		404				{object}	middleware.HttpError
+//	@Failure		500				{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/jobs/{id}/events [get]
+func JobRunsEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := request.GetUUIDParam(c, "id")
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		if _, err := models.GetJobRun(db, id); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		lastID, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		ticker := time.NewTicker(jobEventsPollInterval)
+		defer ticker.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			// Read the run before its events: a run is finished only after
+			// all of its events were stored, so this drains the stream.
+			run, err := models.GetJobRun(db, id)
+			if err != nil {
+				slog.Error("Failed to fetch job run for event stream", "run_id", id, "error", err)
+				return false
+			}
+
+			// A run whose replica died never finishes on its own
+			shown, err := predlogi.ShowOrphanedJobRuns(db, []models.JobRun{run})
+			if err != nil {
+				slog.Error("Failed to check job run for an orphaned run", "run_id", id, "error", err)
+				return false
+			}
+			run = shown[0]
+
+			events, err := models.GetJobRunEventsAfter(db, id, lastID, jobEventsBatchSize)
+			if err != nil {
+				slog.Error("Failed to fetch job run events", "run_id", id, "error", err)
+				return false
+			}
+
+			for _, event := range events {
+				writeServerSentEvent(w, strconv.FormatInt(event.ID, 10), event.Type, newJobRunEventResponse(event))
+				lastID = event.ID
+			}
+
+			if len(events) == jobEventsBatchSize {
+				return true
+			}
+
+			if run.Status != models.JobStatusRunning {
+				writeServerSentEvent(w, "", "finished", newJobRunResponse(run))
+				return false
+			}
+
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+				return true
+			}
+		})
+	}
+}
+
+func writeServerSentEvent(w io.Writer, id, event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Failed to encode server-sent event", "event", event, "error", err)
+		return
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/predlogi"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type JobRunResponse struct {
+	ID                uuid.UUID         `json:"id"`
+	TriggerSource     models.JobTrigger `json:"trigger_source"`
+	DryRun            bool              `json:"dry_run"`
+	Status            models.JobStatus  `json:"status"`
+	StartedAt         time.Time         `json:"started_at"`
+	FinishedAt        *time.Time        `json:"finished_at"`
+	CancelRequestedAt *time.Time        `json:"cancel_requested_at"`
+	TotalUsers        int               `json:"total_users"`
+	SuccessCount      int               `json:"success_count"`
+	FailureCount      int               `json:"failure_count"`
+	SkippedCount      int               `json:"skipped_count"`
+	Error             string            `json:"error,omitempty"`
+	ErrorSummary      json.RawMessage   `json:"error_summary" swaggertype:"array,object"`
+	Stats             json.RawMessage   `json:"stats" swaggertype:"object"`
+}
+
+func newJobRunResponse(run models.JobRun) JobRunResponse {
+	errorSummary := json.RawMessage(run.ErrorSummary)
+	if len(errorSummary) == 0 {
+		errorSummary = json.RawMessage("[]")
+	}
+
+	stats := json.RawMessage(run.Stats)
+	if len(stats) == 0 {
+		stats = json.RawMessage("{}")
+	}
+
+	return JobRunResponse{
+		ID:                run.ID,
+		TriggerSource:     run.TriggerSource,
+		DryRun:            run.DryRun,
+		Status:            run.Status,
+		StartedAt:         run.StartedAt,
+		FinishedAt:        run.FinishedAt,
+		CancelRequestedAt: run.CancelRequestedAt,
+		TotalUsers:        run.TotalUsers,
+		SuccessCount:      run.SuccessCount,
+		FailureCount:      run.FailureCount,
+		SkippedCount:      run.SkippedCount,
+		Error:             run.Error,
+		ErrorSummary:      errorSummary,
+		Stats:             stats,
+	}
+}
+
+type JobRunDetailResponse struct {
+	JobRunResponse
+	Catalog json.RawMessage `json:"catalog" swaggertype:"object"`
+}
+
+func newJobRunDetailResponse(run models.JobRun) JobRunDetailResponse {
+	catalog := json.RawMessage("null")
+	if run.Catalog != nil {
+		catalog = json.RawMessage(*run.Catalog)
+	}
+
+	return JobRunDetailResponse{
+		JobRunResponse: newJobRunResponse(run),
+		Catalog:        catalog,
+	}
+}
+
+// JobRunsList godoc
+//
+//	@Summary		List recommendation job runs
+//	@Description	Lists past and running recommendation job runs, newest first
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit the number of responses"	Default(10)
+//	@Param			offset	query		int		false	"Offset the first response"		Default(0)
+//	@Param			sort	query		string	false	"Sort results"
+//	@Success		200		{object}	request.PaginatedResponse{data=[]JobRunResponse}
+//	@Failure		401		{object}	middleware.HttpError
+//	@Failure		403		{object}	middleware.HttpError
+//	@Failure		500		{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/jobs [get]
+func JobRunsList(c *gin.Context) {
+	tx := middleware.GetContextTransaction(c)
+	pagination := request.GetNormalizedPaginationArgs(c)
+	sort := request.GetSortOptions(c)
+
+	runs, total, err := models.GetJobRuns(tx, pagination, sort)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	runs, err = predlogi.ShowOrphanedJobRuns(tx, runs)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := []JobRunResponse{}
+	for _, run := range runs {
+		response = append(response, newJobRunResponse(run))
+	}
+
+	request.RenderPaginatedResponse(c, response, int(total))
+}
+
+// JobRunsShow godoc
+//
+//	@Summary		Get a recommendation job run
+//	@Description	Returns a single recommendation job run with its outcome counts, errors and the schedule snapshot it used
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string	true	"Job run ID"	Format(uuid)
+//	@Success		200	{object}	JobRunDetailResponse
+//	@Failure		400	{object}	middleware.HttpError
+//	@Failure		401	{object}	middleware.HttpError
+//	@Failure		403	{object}	middleware.HttpError
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/jobs/{id} [get]
+func JobRunsShow(c *gin.Context) {
+	tx := middleware.GetContextTransaction(c)
+
+	id, err := request.GetUUIDParam(c, "id")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	run, err := models.GetJobRun(tx, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	shown, err := predlogi.ShowOrphanedJobRuns(tx, []models.JobRun{run})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newJobRunDetailResponse(shown[0]))
+}
+
+// JobRunsCancel godoc
+//
+//	@Summary		Cancel a running recommendation job
+//	@Description	Stops a running job between users. The run is recorded as cancelled with the counts reached so far.
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string	true	"Job run ID"	Format(uuid)
+//	@Success		202	{object}	JobRunResponse
+//	@Failure		400	{object}	middleware.HttpError
+//	@Failure		401	{object}	middleware.HttpError
+//	@Failure		403	{object}	middleware.HttpError
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		409	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/jobs/{id}/cancel [post]
+func JobRunsCancel(c *gin.Context) {
+	tx := middleware.GetContextTransaction(c)
+
+	id, err := request.GetUUIDParam(c, "id")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	run, err := predlogi.CancelRecommendationJob(tx, id)
+	if errors.Is(err, predlogi.ErrJobNotRunning) {
+		_ = c.Error(&middleware.HttpError{
+			Code:    http.StatusConflict,
+			Message: "Job run is not in progress",
+		})
+		return
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, newJobRunResponse(run))
+}
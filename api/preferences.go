@@ -0,0 +1,200 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// The confirmation form posts back to the page's own URL.
+const unsubscribeConfirmPage = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Unsubscribe</title></head>` +
+	`<body><p>Do you want to stop receiving movie recommendation emails?</p>` +
+	`<form method="post"><button type="submit">Unsubscribe</button></form></body></html>`
+
+const unsubscribedPage = `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Unsubscribed</title></head>` +
+	`<body><p>You will no longer receive movie recommendation emails. You can turn them back on in your account settings.</p></body></html>`
+
+type PreferencesRequest struct {
+	OptedIn          *bool       `json:"opted_in" binding:"required"`
+	MaxEmailsPerWeek *int        `json:"max_emails_per_week" binding:"omitempty,min=1"`
+	PreferredDays    []string    `json:"preferred_days" binding:"omitempty,dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	ExcludedMovieIDs []uuid.UUID `json:"excluded_movie_ids"`
+	ExcludedKeywords []string    `json:"excluded_keywords" binding:"omitempty,dive,required,max=100"`
+}
+
+type PreferencesResponse struct {
+	OptedIn          bool        `json:"opted_in"`
+	MaxEmailsPerWeek *int        `json:"max_emails_per_week"`
+	PreferredDays    []string    `json:"preferred_days"`
+	ExcludedMovieIDs []uuid.UUID `json:"excluded_movie_ids"`
+	ExcludedKeywords []string    `json:"excluded_keywords"`
+	UpdatedAt        *time.Time  `json:"updated_at"`
+}
+
+// apply replaces preferences with the request. Lists left out of the request
+// are cleared, their columns do not accept NULL.
+func (req PreferencesRequest) apply(preferences *models.RecommendationPreferences) {
+	preferences.OptedIn = *req.OptedIn
+	preferences.MaxEmailsPerWeek = req.MaxEmailsPerWeek
+	preferences.PreferredDays = req.PreferredDays
+	preferences.ExcludedMovieIDs = req.ExcludedMovieIDs
+	preferences.ExcludedKeywords = req.ExcludedKeywords
+
+	if preferences.PreferredDays == nil {
+		preferences.PreferredDays = []string{}
+	}
+	if preferences.ExcludedMovieIDs == nil {
+		preferences.ExcludedMovieIDs = []uuid.UUID{}
+	}
+	if preferences.ExcludedKeywords == nil {
+		preferences.ExcludedKeywords = []string{}
+	}
+}
+
+func newPreferencesResponse(preferences models.RecommendationPreferences) PreferencesResponse {
+	response := PreferencesResponse{
+		OptedIn:          preferences.OptedIn,
+		MaxEmailsPerWeek: preferences.MaxEmailsPerWeek,
+		PreferredDays:    preferences.PreferredDays,
+		ExcludedMovieIDs: preferences.ExcludedMovieIDs,
+		ExcludedKeywords: preferences.ExcludedKeywords,
+	}
+	if response.PreferredDays == nil {
+		response.PreferredDays = []string{}
+	}
+	if response.ExcludedMovieIDs == nil {
+		response.ExcludedMovieIDs = []uuid.UUID{}
+	}
+	if response.ExcludedKeywords == nil {
+		response.ExcludedKeywords = []string{}
+	}
+	if !preferences.UpdatedAt.IsZero() {
+		response.UpdatedAt = &preferences.UpdatedAt
+	}
+	return response
+}
+
+// MyPreferencesShow godoc
+//
+//	@Summary		Get my recommendation preferences
+//	@Description	Returns the authenticated user's recommendation preferences, or the defaults if they never changed them
+//	@Tags			preferences
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	PreferencesResponse
+//	@Failure		401	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/preferences/me [get]
+func MyPreferencesShow(c *gin.Context) {
+	tx := middleware.GetContextTransaction(c)
+	userID := middleware.GetContextUserID(c)
+	if c.IsAborted() {
+		return
+	}
+
+	preferences, err := models.GetRecommendationPreferences(tx, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPreferencesResponse(preferences))
+}
+
+// MyPreferencesUpdate godoc
+//
+//	@Summary		Update my recommendation preferences
+//	@Description	Replaces the authenticated user's recommendation preferences
+//	@Tags			preferences
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		PreferencesRequest	true	"Preferences"
+//	@Success		200		{object}	PreferencesResponse
+//	@Failure		400		{object}	middleware.HttpError
+//	@Failure		401		{object}	middleware.HttpError
+//	@Failure		500		{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/preferences/me [put]
+func MyPreferencesUpdate(c *gin.Context) {
+	tx := middleware.GetContextTransaction(c)
+	userID := middleware.GetContextUserID(c)
+	if c.IsAborted() {
+		return
+	}
+
+	var req PreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	preferences, err := models.GetRecommendationPreferences(tx, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	req.apply(&preferences)
+
+	if err := preferences.Save(tx); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPreferencesResponse(preferences))
+}
+
+// UnsubscribeConfirm godoc
+//
+//	@Summary		Confirm unsubscribing
+//	@Description	Shows a page asking the user to confirm unsubscribing. Nothing is changed, so link scanners that fetch the link do not unsubscribe the user.
+//	@Tags			tracking
+//	@Produce		html
+//	@Param			token	path	string	true	"Signed unsubscribe token"
+//	@Success		200
+//	@Failure		404	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/u/{token} [get]
+func UnsubscribeConfirm(links *services.EmailLinks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := links.Verify(services.TokenUnsubscribe, c.Param("token")); err != nil {
+			_ = c.Error(middleware.NewNamedNotFoundError("Link"))
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(unsubscribeConfirmPage))
+	}
+}
+
+// Unsubscribe godoc
+//
+//	@Summary		One-click unsubscribe
+//	@Description	Turns recommendation emails off for the user in the signed token and shows a short confirmation page
+//	@Tags			tracking
+//	@Produce		html
+//	@Param			token	path	string	true	"Signed unsubscribe token"
+//	@Success		200
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/u/{token} [post]
+func Unsubscribe(links *services.EmailLinks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := links.Verify(services.TokenUnsubscribe, c.Param("token"))
+		if err != nil {
+			_ = c.Error(middleware.NewNamedNotFoundError("Link"))
+			return
+		}
+
+		tx := middleware.GetContextTransaction(c)
+		if err := models.OptOutOfRecommendations(tx, userID); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(unsubscribedPage))
+	}
+}
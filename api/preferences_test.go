@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferencesRequestOnlyOptedIn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/predlogi/preferences/me", strings.NewReader(`{"opted_in": false}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req PreferencesRequest
+	require.NoError(t, c.ShouldBindJSON(&req))
+
+	preferences := models.DefaultRecommendationPreferences(uuid.New())
+	preferences.PreferredDays = []string{"friday"}
+	req.apply(&preferences)
+
+	assert.False(t, preferences.OptedIn)
+	assert.Nil(t, preferences.MaxEmailsPerWeek)
+	// Stored as JSON arrays, nil slices would be written as NULL
+	assert.NotNil(t, preferences.PreferredDays)
+	assert.Empty(t, preferences.PreferredDays)
+	assert.NotNil(t, preferences.ExcludedMovieIDs)
+	assert.NotNil(t, preferences.ExcludedKeywords)
+}
+
+func TestUnsubscribeConfirmDoesNotUnsubscribe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PUBLIC_BASE_URL", "https://predlogi.example.com")
+	t.Setenv("RECOMMENDATION_TOKEN_SECRET", "secret")
+
+	links, err := services.NewEmailLinksFromEnv()
+	require.NoError(t, err)
+
+	// No transaction middleware, the confirmation page must not touch the
+	// database
+	router := gin.New()
+	router.GET("/api/v1/predlogi/u/:token", UnsubscribeConfirm(links))
+
+	link := links.UnsubscribeURL(uuid.New())
+	req, _ := http.NewRequest("GET", strings.TrimPrefix(link, "https://predlogi.example.com"), nil)
+	w := performRequest(router, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<form method="post">`)
+}
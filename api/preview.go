@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/PRPO-skupina-02/predlogi/clients/auth"
+	"github.com/PRPO-skupina-02/predlogi/predlogi"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// previewTimeout bounds a preview, which includes a full LLM call.
+const previewTimeout = 2 * time.Minute
+
+type PreviewResponse struct {
+	*services.RecommendationDraft
+	SkipReason string `json:"skip_reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PreviewRecommendation godoc
+//
+//	@Summary		Preview a recommendation for a user
+//	@Description	Runs recommendation generation for a single user and returns the candidates, prompt, raw model output, parsed response and email data.
+//	@Description	Nothing is stored or sent. If generation stops early, the response shows how far it got together with skip_reason or error.
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"	Format(uuid)
+//	@Success		200	{object}	PreviewResponse
+//	@Failure		400	{object}	middleware.HttpError
+//	@Failure		401	{object}	middleware.HttpError
+//	@Failure		403	{object}	middleware.HttpError
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/admin/users/{id}/preview [post]
+func PreviewRecommendation(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := request.GetUUIDParam(c, "id")
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), previewTimeout)
+		defer cancel()
+
+		// The preview only reads, and the LLM call should not hold a
+		// transaction open, so it runs on the root connection and its route
+		// has no transaction middleware.
+		draft, err := predlogi.PreviewRecommendation(ctx, db, userID)
+		if errors.Is(err, auth.ErrUserNotFound) {
+			_ = c.Error(middleware.NewNamedNotFoundError("User"))
+			return
+		}
+		if draft == nil {
+			_ = c.Error(err)
+			return
+		}
+
+		response := PreviewResponse{RecommendationDraft: draft}
+
+		var skipErr *services.SkipError
+		if errors.As(err, &skipErr) {
+			response.SkipReason = skipErr.Reason
+		} else if err != nil {
+			response.Error = err.Error()
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
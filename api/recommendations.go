@@ -0,0 +1,132 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MovieResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	ImageURL      string    `json:"image_url"`
+	Rating        float64   `json:"rating"`
+	LengthMinutes int       `json:"length_minutes"`
+}
+
+func newMovieResponse(movie *spored.Movie) *MovieResponse {
+	if movie == nil {
+		return nil
+	}
+
+	return &MovieResponse{
+		ID:            movie.ID,
+		Title:         movie.Title,
+		Description:   movie.Description,
+		ImageURL:      movie.ImageURL,
+		Rating:        movie.Rating,
+		LengthMinutes: movie.LengthMinutes,
+	}
+}
+
+type RecommendationResponse struct {
+	ID              uuid.UUID                   `json:"id"`
+	CreatedAt       time.Time                   `json:"created_at"`
+	MovieID         uuid.UUID                   `json:"movie_id"`
+	Movie           *MovieResponse              `json:"movie"`
+	Reason          string                      `json:"reason"`
+	ConfidenceScore float64                     `json:"confidence_score"`
+	Status          models.RecommendationStatus `json:"status"`
+	SentAt          *time.Time                  `json:"sent_at"`
+	OpenedAt        *time.Time                  `json:"opened_at"`
+	ClickedAt       *time.Time                  `json:"clicked_at"`
+	ReservationURL  string                      `json:"reservation_url"`
+}
+
+func newRecommendationResponse(recommendation models.Recommendation, movie *spored.Movie) RecommendationResponse {
+	return RecommendationResponse{
+		ID:              recommendation.ID,
+		CreatedAt:       recommendation.CreatedAt,
+		MovieID:         recommendation.MovieID,
+		Movie:           newMovieResponse(movie),
+		Reason:          recommendation.Reason,
+		ConfidenceScore: recommendation.ConfidenceScore,
+		Status:          recommendation.Status,
+		SentAt:          recommendation.SentAt,
+		OpenedAt:        recommendation.OpenedAt,
+		ClickedAt:       recommendation.ClickedAt,
+		ReservationURL:  recommendation.ReservationURL,
+	}
+}
+
+// lookupMovies fetches the movies of a page of recommendations from spored.
+// Movies that cannot be fetched are left out so the page still renders.
+func lookupMovies(sporedClient *spored.Client, recommendations []models.Recommendation) map[uuid.UUID]*spored.Movie {
+	cached := spored.NewCachedClient(sporedClient)
+	movies := map[uuid.UUID]*spored.Movie{}
+
+	for _, recommendation := range recommendations {
+		if _, ok := movies[recommendation.MovieID]; ok {
+			continue
+		}
+
+		movie, err := cached.GetMovie(recommendation.MovieID)
+		if err != nil {
+			slog.Warn("Failed to fetch recommended movie", "movie_id", recommendation.MovieID, "error", err)
+			movies[recommendation.MovieID] = nil
+			continue
+		}
+		movies[recommendation.MovieID] = movie
+	}
+
+	return movies
+}
+
+// MyRecommendationsList godoc
+//
+//	@Summary		List my recommendations
+//	@Description	Lists the recommendations delivered to the authenticated user with movie details, newest first
+//	@Tags			recommendations
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit the number of responses"	Default(10)
+//	@Param			offset	query		int		false	"Offset the first response"		Default(0)
+//	@Param			sort	query		string	false	"Sort results"
+//	@Success		200		{object}	request.PaginatedResponse{data=[]RecommendationResponse}
+//	@Failure		401		{object}	middleware.HttpError
+//	@Failure		500		{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/recommendations/me [get]
+func MyRecommendationsList(sporedClient *spored.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx := middleware.GetContextTransaction(c)
+		userID := middleware.GetContextUserID(c)
+		if c.IsAborted() {
+			return
+		}
+
+		pagination := request.GetNormalizedPaginationArgs(c)
+		sort := request.GetSortOptions(c)
+
+		recommendations, total, err := models.GetRecommendationsByUser(tx, userID, pagination, sort)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		movies := lookupMovies(sporedClient, recommendations)
+
+		response := []RecommendationResponse{}
+		for _, recommendation := range recommendations {
+			response = append(response, newRecommendationResponse(recommendation, movies[recommendation.MovieID]))
+		}
+
+		request.RenderPaginatedResponse(c, response, int(total))
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/PRPO-skupina-02/common/middleware"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/gin-gonic/gin"
+)
+
+// trackingPixel is a transparent 1x1 GIF.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// TrackOpen godoc
+//
+//	@Summary		Email open tracking pixel
+//	@Description	Serves a 1x1 image and marks the recommendation in the signed token as opened on the first request
+//	@Tags			tracking
+//	@Produce		image/gif
+//	@Param			token	path	string	true	"Signed open token"
+//	@Success		200
+//	@Failure		404
+//	@Router			/api/v1/predlogi/o/{token} [get]
+func TrackOpen(links *services.EmailLinks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := links.Verify(services.TokenOpen, c.Param("token"))
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		tx := middleware.GetContextTransaction(c)
+		if err := models.MarkRecommendationAsOpened(tx, id); err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		c.Header("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+		c.Data(http.StatusOK, "image/gif", trackingPixel)
+	}
+}
+
+// TrackClick godoc
+//
+//	@Summary		Recommendation click-through redirect
+//	@Description	Marks the recommendation in the signed token as clicked and redirects to the reservation page
+//	@Tags			tracking
+//	@Param			token	path	string	true	"Signed click token"
+//	@Success		302
+//	@Failure		404	{object}	middleware.HttpError
+//	@Failure		500	{object}	middleware.HttpError
+//	@Router			/api/v1/predlogi/r/{token} [get]
+func TrackClick(links *services.EmailLinks) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := links.Verify(services.TokenClick, c.Param("token"))
+		if err != nil {
+			_ = c.Error(middleware.NewNamedNotFoundError("Link"))
+			return
+		}
+
+		tx := middleware.GetContextTransaction(c)
+
+		recommendation, err := models.GetRecommendation(tx, id)
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+
+		// Failing to record the click must not keep the user from the page
+		if err := models.MarkRecommendationAsClicked(tx, id); err != nil {
+			slog.Error("Failed to mark recommendation as clicked", "recommendation_id", id, "error", err)
+		}
+
+		c.Redirect(http.StatusFound, links.ReservationURL(recommendation))
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrUserNotFound is returned when the auth service does not know a user.
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRole string
 
 const (
@@ -88,6 +92,10 @@ func (c *Client) GetUser(userID uuid.UUID) (*User, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
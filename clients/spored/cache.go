@@ -0,0 +1,97 @@
+package spored
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// CacheStats counts lookups served by a CachedClient. A hit is a lookup that
+// was answered from the cache or joined a request already in flight.
+type CacheStats struct {
+	TimeSlotHits   int64 `json:"timeslot_hits"`
+	TimeSlotMisses int64 `json:"timeslot_misses"`
+	MovieHits      int64 `json:"movie_hits"`
+	MovieMisses    int64 `json:"movie_misses"`
+}
+
+// Lookup fetches single timeslots and movies. Client implements it.
+type Lookup interface {
+	GetTimeSlot(timeSlotID uuid.UUID) (*TimeSlot, error)
+	GetMovie(movieID uuid.UUID) (*Movie, error)
+}
+
+// CachedClient memoizes timeslot and movie lookups of a Client. It is meant
+// to live for a single job run, so entries never expire. Failed lookups are
+// not cached.
+type CachedClient struct {
+	client Lookup
+
+	timeSlots memo[TimeSlot]
+	movies    memo[Movie]
+}
+
+func NewCachedClient(client Lookup) *CachedClient {
+	return &CachedClient{client: client}
+}
+
+func (c *CachedClient) GetTimeSlot(timeSlotID uuid.UUID) (*TimeSlot, error) {
+	return c.timeSlots.get(timeSlotID, c.client.GetTimeSlot)
+}
+
+func (c *CachedClient) GetMovie(movieID uuid.UUID) (*Movie, error) {
+	return c.movies.get(movieID, c.client.GetMovie)
+}
+
+func (c *CachedClient) Stats() CacheStats {
+	return CacheStats{
+		TimeSlotHits:   c.timeSlots.hits.Load(),
+		TimeSlotMisses: c.timeSlots.misses.Load(),
+		MovieHits:      c.movies.hits.Load(),
+		MovieMisses:    c.movies.misses.Load(),
+	}
+}
+
+type memoCall[T any] struct {
+	done  chan struct{}
+	value *T
+	err   error
+}
+
+// memo deduplicates lookups by ID, including concurrent ones.
+type memo[T any] struct {
+	mu     sync.Mutex
+	calls  map[uuid.UUID]*memoCall[T]
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func (m *memo[T]) get(id uuid.UUID, fetch func(uuid.UUID) (*T, error)) (*T, error) {
+	m.mu.Lock()
+	if m.calls == nil {
+		m.calls = make(map[uuid.UUID]*memoCall[T])
+	}
+
+	if call, ok := m.calls[id]; ok {
+		m.mu.Unlock()
+		m.hits.Add(1)
+		<-call.done
+		return call.value, call.err
+	}
+
+	call := &memoCall[T]{done: make(chan struct{})}
+	m.calls[id] = call
+	m.mu.Unlock()
+	m.misses.Add(1)
+
+	call.value, call.err = fetch(id)
+	if call.err != nil {
+		m.mu.Lock()
+		delete(m.calls, id)
+		m.mu.Unlock()
+	}
+	close(call.done)
+
+	return call.value, call.err
+}
@@ -0,0 +1,65 @@
+package spored
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoDeduplicatesLookups(t *testing.T) {
+	var m memo[Movie]
+	var fetches atomic.Int64
+	id := uuid.New()
+
+	fetch := func(movieID uuid.UUID) (*Movie, error) {
+		fetches.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return &Movie{ID: movieID, Title: "Test"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			movie, err := m.get(id, fetch)
+			assert.NoError(t, err)
+			assert.Equal(t, id, movie.ID)
+		}()
+	}
+	wg.Wait()
+
+	_, err := m.get(id, fetch)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(1), fetches.Load())
+	assert.Equal(t, int64(1), m.misses.Load())
+	assert.Equal(t, int64(10), m.hits.Load())
+}
+
+func TestMemoDoesNotCacheErrors(t *testing.T) {
+	var m memo[TimeSlot]
+	id := uuid.New()
+	calls := 0
+
+	fetch := func(uuid.UUID) (*TimeSlot, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("unavailable")
+		}
+		return &TimeSlot{ID: id}, nil
+	}
+
+	_, err := m.get(id, fetch)
+	assert.Error(t, err)
+
+	timeSlot, err := m.get(id, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, id, timeSlot.ID)
+	assert.Equal(t, 2, calls)
+}
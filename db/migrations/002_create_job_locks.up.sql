@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS job_locks (
+    name VARCHAR(255) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    locked_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    trigger_source VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'running',
+
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+
+    total_users INT NOT NULL DEFAULT 0,
+    success_count INT NOT NULL DEFAULT 0,
+    failure_count INT NOT NULL DEFAULT 0,
+
+    error TEXT,
+    error_summary JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_started_at ON job_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_job_runs_status ON job_runs(status);
@@ -0,0 +1 @@
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS cancel_requested_at TIMESTAMP;
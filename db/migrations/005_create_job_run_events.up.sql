@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS job_run_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    job_run_id UUID NOT NULL REFERENCES job_runs(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+
+    user_id UUID,
+    user_index INT,
+    total_users INT,
+
+    movie_id UUID,
+    movie_title VARCHAR(500),
+
+    message TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_run_events_job_run_id ON job_run_events(job_run_id, id);
@@ -0,0 +1 @@
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS catalog JSONB;
@@ -0,0 +1 @@
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS stats JSONB NOT NULL DEFAULT '{}';
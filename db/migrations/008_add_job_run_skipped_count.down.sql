@@ -0,0 +1 @@
+ALTER TABLE job_runs DROP COLUMN IF EXISTS skipped_count;
@@ -0,0 +1 @@
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS skipped_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE recommendations ADD COLUMN IF NOT EXISTS reservation_url TEXT;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS recommendation_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    recommendation_id UUID NOT NULL UNIQUE REFERENCES recommendations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    movie_id UUID NOT NULL,
+
+    type VARCHAR(50) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_recommendation_feedback_user_id ON recommendation_feedback(user_id);
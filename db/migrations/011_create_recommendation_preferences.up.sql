@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS recommendation_preferences (
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    opted_in BOOLEAN NOT NULL DEFAULT TRUE,
+    max_emails_per_week INT,
+
+    preferred_days JSONB NOT NULL DEFAULT '[]',
+    excluded_movie_ids JSONB NOT NULL DEFAULT '[]',
+    excluded_keywords JSONB NOT NULL DEFAULT '[]'
+);
@@ -0,0 +1 @@
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS dry_run BOOLEAN NOT NULL DEFAULT FALSE;
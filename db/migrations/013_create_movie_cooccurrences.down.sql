@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS movie_cooccurrences;
+DROP TABLE IF EXISTS user_movie_bookings;
@@ -0,0 +1,21 @@
+-- Movies each user has booked, so the co-occurrence matrix can be updated
+-- incrementally with only new bookings.
+CREATE TABLE IF NOT EXISTS user_movie_bookings (
+    user_id UUID NOT NULL,
+    movie_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, movie_id)
+);
+
+-- How many users booked both movie_a and movie_b. Pairs are stored in both
+-- directions, and the diagonal (movie_a = movie_b) counts the users that
+-- booked a movie at all.
+CREATE TABLE IF NOT EXISTS movie_cooccurrences (
+    movie_a UUID NOT NULL,
+    movie_b UUID NOT NULL,
+    count INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (movie_a, movie_b)
+);
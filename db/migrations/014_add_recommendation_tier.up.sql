@@ -0,0 +1 @@
+ALTER TABLE recommendations ADD COLUMN IF NOT EXISTS tier VARCHAR(50) NOT NULL DEFAULT 'primary';
@@ -0,0 +1 @@
+ALTER TABLE job_locks DROP COLUMN IF EXISTS released_at;
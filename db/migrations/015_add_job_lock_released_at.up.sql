@@ -0,0 +1 @@
+ALTER TABLE job_locks ADD COLUMN IF NOT EXISTS released_at TIMESTAMP;
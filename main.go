@@ -9,8 +9,10 @@ import (
 	"github.com/PRPO-skupina-02/common/logging"
 	"github.com/PRPO-skupina-02/common/validation"
 	"github.com/PRPO-skupina-02/predlogi/api"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
 	"github.com/PRPO-skupina-02/predlogi/db"
 	"github.com/PRPO-skupina-02/predlogi/predlogi"
+	"github.com/PRPO-skupina-02/predlogi/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -39,6 +41,11 @@ func run() error {
 		return err
 	}
 
+	links, err := services.NewEmailLinksFromEnv()
+	if err != nil {
+		return err
+	}
+
 	// Setup cron scheduler for recommendation job
 	err = predlogi.SetupCron(database)
 	if err != nil {
@@ -62,7 +69,8 @@ func run() error {
 		c.Next()
 	})
 
-	api.Register(router, database, trans)
+	sporedClient := spored.NewClient(os.Getenv("SPORED_HOST"))
+	api.Register(router, database, trans, links, sporedClient)
 
 	slog.Info("Server startup complete")
 	err = router.Run(":8080")
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserMovieBooking records that a user booked a movie at least once.
+type UserMovieBooking struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key"`
+	MovieID   uuid.UUID `gorm:"type:uuid;primary_key"`
+	CreatedAt time.Time
+}
+
+// MovieCooccurrence counts the users that booked both MovieA and MovieB.
+type MovieCooccurrence struct {
+	MovieA    uuid.UUID `gorm:"type:uuid;primary_key"`
+	MovieB    uuid.UUID `gorm:"type:uuid;primary_key"`
+	Count     int       `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// SetUserBookings replaces the movies recorded for a user with movieIDs and
+// updates the co-occurrence matrix by the difference. Movies the user no
+// longer has a booking for, e.g. after cancelling it, are counted out again,
+// so it is meant to be called with the user's full history on every run.
+func SetUserBookings(tx *gorm.DB, userID uuid.UUID, movieIDs []uuid.UUID) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		var known []uuid.UUID
+		if err := tx.Model(&UserMovieBooking{}).Where("user_id = ?", userID).Pluck("movie_id", &known).Error; err != nil {
+			return err
+		}
+
+		before := make(map[uuid.UUID]bool, len(known))
+		for _, movieID := range known {
+			before[movieID] = true
+		}
+		after := make(map[uuid.UUID]bool, len(movieIDs))
+		for _, movieID := range movieIDs {
+			after[movieID] = true
+		}
+
+		removed := movieDifference(before, after)
+		added := movieDifference(after, before)
+		if len(removed) == 0 && len(added) == 0 {
+			return nil
+		}
+
+		if len(removed) > 0 {
+			if err := tx.Where("user_id = ? AND movie_id IN ?", userID, removed).Delete(&UserMovieBooking{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(added) > 0 {
+			bookings := make([]UserMovieBooking, 0, len(added))
+			for _, movieID := range added {
+				bookings = append(bookings, UserMovieBooking{UserID: userID, MovieID: movieID})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bookings).Error; err != nil {
+				return err
+			}
+		}
+
+		// One upsert in a fixed order, so users updated concurrently lock
+		// the cells they share in the same order
+		cells := cooccurrenceDeltas(removed, before, added, after)
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "movie_a"}, {Name: "movie_b"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "count"}, Value: gorm.Expr("movie_cooccurrences.count + EXCLUDED.count")},
+				{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+			},
+		}).CreateInBatches(cells, 500).Error; err != nil {
+			return err
+		}
+
+		// Pairs nobody booked anymore would only be skipped when scoring.
+		// Only cells locked by the upsert above are looked at.
+		decremented := [][]any{}
+		for _, cell := range cells {
+			if cell.Count < 0 {
+				decremented = append(decremented, []any{cell.MovieA, cell.MovieB})
+			}
+		}
+		if len(decremented) == 0 {
+			return nil
+		}
+		return tx.Where("(movie_a, movie_b) IN ? AND count <= 0", decremented).Delete(&MovieCooccurrence{}).Error
+	})
+}
+
+// movieDifference returns the movies in a that are not in b.
+func movieDifference(a, b map[uuid.UUID]bool) []uuid.UUID {
+	difference := []uuid.UUID{}
+	for movieID := range a {
+		if !b[movieID] {
+			difference = append(difference, movieID)
+		}
+	}
+	return difference
+}
+
+// cooccurrenceDeltas merges the changes of removing movies from a user who
+// had the movies in before and adding movies to a user who has the movies in
+// after into one change per cell. Cells are sorted so concurrent updates lock
+// rows in the same order.
+func cooccurrenceDeltas(removed []uuid.UUID, before map[uuid.UUID]bool, added []uuid.UUID, after map[uuid.UUID]bool) []MovieCooccurrence {
+	deltas := make(map[[2]uuid.UUID]int)
+	for _, cell := range append(cooccurrenceChanges(removed, before, -1), cooccurrenceChanges(added, after, 1)...) {
+		deltas[[2]uuid.UUID{cell.MovieA, cell.MovieB}] += cell.Count
+	}
+
+	cells := make([]MovieCooccurrence, 0, len(deltas))
+	for pair, delta := range deltas {
+		if delta != 0 {
+			cells = append(cells, MovieCooccurrence{MovieA: pair[0], MovieB: pair[1], Count: delta})
+		}
+	}
+
+	sort.Slice(cells, func(i, j int) bool {
+		if c := bytes.Compare(cells[i].MovieA[:], cells[j].MovieA[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(cells[i].MovieB[:], cells[j].MovieB[:]) < 0
+	})
+
+	return cells
+}
+
+// cooccurrenceChanges returns the matrix cells that change by delta when the
+// changed movies are added to or removed from a user whose movies, including
+// the changed ones, are in all: every pair with at least one changed movie,
+// in both directions, plus the diagonal of the changed movies.
+func cooccurrenceChanges(changed []uuid.UUID, all map[uuid.UUID]bool, delta int) []MovieCooccurrence {
+	isChanged := make(map[uuid.UUID]bool, len(changed))
+	for _, movieID := range changed {
+		isChanged[movieID] = true
+	}
+
+	cells := []MovieCooccurrence{}
+	for _, a := range changed {
+		cells = append(cells, MovieCooccurrence{MovieA: a, MovieB: a, Count: delta})
+		for b := range all {
+			if a == b {
+				continue
+			}
+			// A pair of two changed movies is visited twice, count it once
+			if isChanged[b] && bytes.Compare(a[:], b[:]) > 0 {
+				continue
+			}
+			cells = append(cells,
+				MovieCooccurrence{MovieA: a, MovieB: b, Count: delta},
+				MovieCooccurrence{MovieA: b, MovieB: a, Count: delta},
+			)
+		}
+	}
+
+	return cells
+}
+
+// GetMovieCooccurrences returns the matrix cells from the given movies to the
+// given movies.
+func GetMovieCooccurrences(tx *gorm.DB, from, to []uuid.UUID) ([]MovieCooccurrence, error) {
+	var cells []MovieCooccurrence
+	if len(from) == 0 || len(to) == 0 {
+		return cells, nil
+	}
+	err := tx.Where("movie_a IN ? AND movie_b IN ?", from, to).Find(&cells).Error
+	return cells, err
+}
+
+// GetMoviePopularity returns how many users booked each of the given movies,
+// taken from the diagonal of the matrix.
+func GetMoviePopularity(tx *gorm.DB, movieIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	popularity := make(map[uuid.UUID]int)
+	if len(movieIDs) == 0 {
+		return popularity, nil
+	}
+
+	var cells []MovieCooccurrence
+	if err := tx.Where("movie_a = movie_b AND movie_a IN ?", movieIDs).Find(&cells).Error; err != nil {
+		return popularity, err
+	}
+	for _, cell := range cells {
+		popularity[cell.MovieA] = cell.Count
+	}
+	return popularity, nil
+}
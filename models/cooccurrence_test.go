@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooccurrenceChanges(t *testing.T) {
+	known := uuid.New()
+	first := uuid.New()
+	second := uuid.New()
+
+	all := map[uuid.UUID]bool{known: true, first: true, second: true}
+	cells := cooccurrenceChanges([]uuid.UUID{first, second}, all, 1)
+
+	counts := make(map[[2]uuid.UUID]int)
+	for _, cell := range cells {
+		counts[[2]uuid.UUID{cell.MovieA, cell.MovieB}] += cell.Count
+	}
+
+	assert.Equal(t, map[[2]uuid.UUID]int{
+		{first, first}:   1,
+		{second, second}: 1,
+		{first, known}:   1,
+		{known, first}:   1,
+		{second, known}:  1,
+		{known, second}:  1,
+		{first, second}:  1,
+		{second, first}:  1,
+	}, counts)
+}
+
+func TestCooccurrenceChangesRemoved(t *testing.T) {
+	kept := uuid.New()
+	cancelled := uuid.New()
+
+	all := map[uuid.UUID]bool{kept: true, cancelled: true}
+	cells := cooccurrenceChanges([]uuid.UUID{cancelled}, all, -1)
+
+	assert.ElementsMatch(t, []MovieCooccurrence{
+		{MovieA: cancelled, MovieB: cancelled, Count: -1},
+		{MovieA: cancelled, MovieB: kept, Count: -1},
+		{MovieA: kept, MovieB: cancelled, Count: -1},
+	}, cells)
+}
+
+func TestCooccurrenceDeltas(t *testing.T) {
+	kept := uuid.New()
+	cancelled := uuid.New()
+	booked := uuid.New()
+
+	before := map[uuid.UUID]bool{kept: true, cancelled: true}
+	after := map[uuid.UUID]bool{kept: true, booked: true}
+	cells := cooccurrenceDeltas([]uuid.UUID{cancelled}, before, []uuid.UUID{booked}, after)
+
+	assert.ElementsMatch(t, []MovieCooccurrence{
+		{MovieA: cancelled, MovieB: cancelled, Count: -1},
+		{MovieA: cancelled, MovieB: kept, Count: -1},
+		{MovieA: kept, MovieB: cancelled, Count: -1},
+		{MovieA: booked, MovieB: booked, Count: 1},
+		{MovieA: booked, MovieB: kept, Count: 1},
+		{MovieA: kept, MovieB: booked, Count: 1},
+	}, cells)
+}
+
+// Two users swapping the same two movies touch the same cells, one removing
+// where the other adds. Both updates must lock the shared cells in the same
+// order, or running them concurrently can deadlock.
+func TestCooccurrenceDeltasLockOrder(t *testing.T) {
+	shared := uuid.New()
+	first := uuid.New()
+	second := uuid.New()
+
+	updates := [][]MovieCooccurrence{
+		cooccurrenceDeltas(
+			[]uuid.UUID{first}, map[uuid.UUID]bool{shared: true, first: true},
+			[]uuid.UUID{second}, map[uuid.UUID]bool{shared: true, second: true},
+		),
+		cooccurrenceDeltas(
+			[]uuid.UUID{second}, map[uuid.UUID]bool{shared: true, second: true},
+			[]uuid.UUID{first}, map[uuid.UUID]bool{shared: true, first: true},
+		),
+	}
+
+	pairs := make([][][2]uuid.UUID, len(updates))
+	for i, cells := range updates {
+		for _, cell := range cells {
+			pairs[i] = append(pairs[i], [2]uuid.UUID{cell.MovieA, cell.MovieB})
+		}
+	}
+
+	assert.Len(t, pairs[0], 6)
+	assert.Equal(t, pairs[0], pairs[1])
+	assert.Equal(t, -updates[0][0].Count, updates[1][0].Count)
+}
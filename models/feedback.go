@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeedbackType string
+
+const (
+	FeedbackLike          FeedbackType = "like"
+	FeedbackDislike       FeedbackType = "dislike"
+	FeedbackNotInterested FeedbackType = "not_interested"
+)
+
+// Valid reports whether t is one of the known feedback types.
+func (t FeedbackType) Valid() bool {
+	switch t {
+	case FeedbackLike, FeedbackDislike, FeedbackNotInterested:
+		return true
+	}
+	return false
+}
+
+// Negative reports whether the user asked not to be recommended the movie
+// again.
+func (t FeedbackType) Negative() bool {
+	return t == FeedbackDislike || t == FeedbackNotInterested
+}
+
+// RecommendationFeedback is a user's verdict on a recommendation. A
+// recommendation has at most one, and newer feedback replaces older.
+type RecommendationFeedback struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	RecommendationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	UserID           uuid.UUID `gorm:"type:uuid;not null;index"`
+	MovieID          uuid.UUID `gorm:"type:uuid;not null"`
+
+	Type FeedbackType `gorm:"type:varchar(50);not null"`
+}
+
+func (RecommendationFeedback) TableName() string {
+	return "recommendation_feedback"
+}
+
+// Upsert stores the feedback, replacing any earlier feedback on the same
+// recommendation.
+func (f *RecommendationFeedback) Upsert(tx *gorm.DB) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "recommendation_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "updated_at"}),
+	}).Create(f).Error
+}
+
+// GetFeedbackByUser returns all feedback a user has given, newest first.
+func GetFeedbackByUser(tx *gorm.DB, userID uuid.UUID) ([]RecommendationFeedback, error) {
+	var feedback []RecommendationFeedback
+	err := tx.Where("user_id = ?", userID).Order("updated_at DESC").Find(&feedback).Error
+	return feedback, err
+}
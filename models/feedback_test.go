@@ -0,0 +1,15 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedbackType(t *testing.T) {
+	assert.True(t, FeedbackLike.Valid())
+	assert.False(t, FeedbackLike.Negative())
+	assert.True(t, FeedbackDislike.Negative())
+	assert.True(t, FeedbackNotInterested.Negative())
+	assert.False(t, FeedbackType("meh").Valid())
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// EqualFilter matches rows where Column equals Value. Column may be an SQL
+// expression such as LOWER(email_to); it must never come from user input.
+type EqualFilter struct {
+	Column string
+	Value  any
+}
+
+func (f EqualFilter) Apply(db *gorm.DB) *gorm.DB {
+	return db.Where(fmt.Sprintf("%s = ?", f.Column), f.Value)
+}
+
+// RangeFilter matches rows where Column lies within [Min, Max]. A nil bound
+// leaves that side of the range open.
+type RangeFilter struct {
+	Column string
+	Min    any
+	Max    any
+}
+
+func (f RangeFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.Min != nil {
+		db = db.Where(fmt.Sprintf("%s >= ?", f.Column), f.Min)
+	}
+	if f.Max != nil {
+		db = db.Where(fmt.Sprintf("%s <= ?", f.Column), f.Max)
+	}
+	return db
+}
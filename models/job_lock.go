@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobLock is a lease on a named job shared by all replicas. A lease is free
+// once ExpiresAt has passed, regardless of who holds it. A released lease may
+// still be held for a while, see ReleaseJobLock.
+type JobLock struct {
+	Name       string `gorm:"type:varchar(255);primary_key"`
+	Holder     string `gorm:"type:varchar(255);not null"`
+	LockedAt   time.Time
+	ExpiresAt  time.Time
+	ReleasedAt *time.Time
+}
+
+// AcquireJobLock takes the lease for name on behalf of holder for ttl. It
+// returns false if another holder owns a lease that has not expired yet.
+// With ignoreHold, a lease that was released but is still held is taken over
+// too. Database time is used throughout so clock skew between replicas does
+// not matter.
+func AcquireJobLock(tx *gorm.DB, name, holder string, ttl time.Duration, ignoreHold bool) (bool, error) {
+	result := tx.Exec(`
+		INSERT INTO job_locks (name, holder, locked_at, expires_at)
+		VALUES (?, ?, NOW(), NOW() + (? * INTERVAL '1 second'))
+		ON CONFLICT (name) DO UPDATE
+		SET holder = EXCLUDED.holder, locked_at = EXCLUDED.locked_at, expires_at = EXCLUDED.expires_at, released_at = NULL
+		WHERE job_locks.expires_at < NOW() OR (? AND job_locks.released_at IS NOT NULL)`,
+		name, holder, ttl.Seconds(), ignoreHold,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RenewJobLock extends the lease held by holder to ttl from now. It returns
+// false if holder no longer owns the lease.
+func RenewJobLock(tx *gorm.DB, name, holder string, ttl time.Duration) (bool, error) {
+	result := tx.Exec(`
+		UPDATE job_locks
+		SET expires_at = NOW() + (? * INTERVAL '1 second')
+		WHERE name = ? AND holder = ? AND released_at IS NULL`,
+		ttl.Seconds(), name, holder,
+	)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseJobLock ends the lease held by holder. The lease is kept until at
+// least hold has passed since it was acquired, so replicas that fire the same
+// schedule slightly later still see it as taken.
+func ReleaseJobLock(tx *gorm.DB, name, holder string, hold time.Duration) error {
+	return tx.Exec(`
+		UPDATE job_locks
+		SET expires_at = GREATEST(NOW(), locked_at + (? * INTERVAL '1 second')), released_at = NOW()
+		WHERE name = ? AND holder = ?`,
+		hold.Seconds(), name, holder,
+	).Error
+}
+
+// HasLiveJobLock reports whether a replica holds an unreleased lease on name
+// that has not expired yet.
+func HasLiveJobLock(tx *gorm.DB, name string) (bool, error) {
+	var count int64
+	err := tx.Model(&JobLock{}).
+		Where("name = ? AND released_at IS NULL AND expires_at > NOW()", name).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"time"
+
+	"github.com/PRPO-skupina-02/common/request"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerCron    JobTrigger = "cron"
+	JobTriggerStartup JobTrigger = "startup"
+	JobTriggerAdmin   JobTrigger = "admin"
+)
+
+type JobStatus string
+
+const (
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+type JobRun struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	TriggerSource JobTrigger `gorm:"type:varchar(50);not null"`
+	Status        JobStatus  `gorm:"type:varchar(50);default:'running';index"`
+	DryRun        bool       `gorm:"not null"` // Recommendations are stored but never emailed
+
+	StartedAt         time.Time `gorm:"not null;index"`
+	FinishedAt        *time.Time
+	CancelRequestedAt *time.Time
+
+	TotalUsers   int
+	SuccessCount int
+	FailureCount int
+	SkippedCount int
+
+	Error        string  `gorm:"type:text"`
+	ErrorSummary string  `gorm:"type:jsonb;default:'[]'"` // Per-user errors, capped
+	Catalog      *string `gorm:"type:jsonb"`              // Schedule snapshot used by the run
+	Stats        string  `gorm:"type:jsonb;default:'{}'"` // Counters collected during the run
+}
+
+func (r *JobRun) Create(tx *gorm.DB) error {
+	if err := tx.Create(r).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *JobRun) Save(tx *gorm.DB) error {
+	if err := tx.Save(r).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// Finish stores the outcome of a run without overwriting columns that may
+// have been changed concurrently, such as cancel requests.
+func (r *JobRun) Finish(tx *gorm.DB) error {
+	return tx.Model(r).
+		Select("status", "finished_at", "total_users", "success_count", "failure_count", "skipped_count", "error", "error_summary", "catalog", "stats").
+		Updates(r).Error
+}
+
+func GetJobRun(tx *gorm.DB, id uuid.UUID) (JobRun, error) {
+	var run JobRun
+	if err := tx.Where("id = ?", id).First(&run).Error; err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+func GetJobRuns(tx *gorm.DB, pagination *request.PaginationOptions, sort *request.SortOptions) ([]JobRun, int64, error) {
+	var runs []JobRun
+	var total int64
+
+	query := tx.Model(&JobRun{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return runs, 0, err
+	}
+
+	if sort == nil {
+		sort = &request.SortOptions{Column: "started_at", Desc: true}
+	}
+
+	if err := query.Scopes(request.PaginateScope(pagination), request.SortScope(sort)).Find(&runs).Error; err != nil {
+		return runs, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// interruptedRunError is recorded on runs whose replica stopped before
+// finishing them, for example because it was shut down during a deploy.
+const interruptedRunError = "Run was interrupted, the replica running it stopped"
+
+// FailInterruptedJobRuns marks every running run as failed. It must only be
+// called while holding the job lock, when no run can be in progress.
+func FailInterruptedJobRuns(tx *gorm.DB) (int64, error) {
+	result := tx.Model(&JobRun{}).
+		Where("status = ?", JobStatusRunning).
+		Updates(map[string]any{
+			"status":      JobStatusFailed,
+			"finished_at": time.Now(),
+			"error":       interruptedRunError,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// FailOrphanedJobRuns marks running runs as failed if nobody holds a live
+// lease on lockName. A run only finishes before its lease is released, so
+// runs left running without a lease were interrupted.
+func FailOrphanedJobRuns(tx *gorm.DB, lockName string) (int64, error) {
+	result := tx.Model(&JobRun{}).
+		Where("status = ?", JobStatusRunning).
+		Where("NOT EXISTS (SELECT 1 FROM job_locks WHERE name = ? AND released_at IS NULL AND expires_at > NOW())", lockName).
+		Updates(map[string]any{
+			"status":      JobStatusFailed,
+			"finished_at": time.Now(),
+			"error":       interruptedRunError,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// AsInterrupted returns the run the way FailOrphanedJobRuns records it, for
+// readers that should not write.
+func (r JobRun) AsInterrupted() JobRun {
+	r.Status = JobStatusFailed
+	r.Error = interruptedRunError
+	return r
+}
+
+// RequestJobRunCancel flags a running job run for cancellation. The replica
+// executing the run picks the flag up and stops it.
+func RequestJobRunCancel(tx *gorm.DB, id uuid.UUID) error {
+	return tx.Model(&JobRun{}).
+		Where("id = ? AND status = ? AND cancel_requested_at IS NULL", id, JobStatusRunning).
+		Update("cancel_requested_at", time.Now()).Error
+}
+
+func IsJobRunCancelRequested(tx *gorm.DB, id uuid.UUID) (bool, error) {
+	var count int64
+	err := tx.Model(&JobRun{}).Where("id = ? AND cancel_requested_at IS NOT NULL", id).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobRunEvent is a single progress event of a job run, such as a user being
+// processed or an email being published.
+type JobRunEvent struct {
+	ID        int64 `gorm:"primary_key;autoIncrement"`
+	CreatedAt time.Time
+
+	JobRunID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Type     string    `gorm:"type:varchar(50);not null"`
+
+	UserID     *uuid.UUID `gorm:"type:uuid"`
+	UserIndex  int
+	TotalUsers int
+
+	MovieID    *uuid.UUID `gorm:"type:uuid"`
+	MovieTitle string
+
+	Message string `gorm:"type:text"`
+}
+
+func (e *JobRunEvent) Create(tx *gorm.DB) error {
+	if err := tx.Create(e).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetJobRunEventsAfter returns up to limit events of a run with an ID greater
+// than afterID, oldest first.
+func GetJobRunEventsAfter(tx *gorm.DB, runID uuid.UUID, afterID int64, limit int) ([]JobRunEvent, error) {
+	var events []JobRunEvent
+	err := tx.Where("job_run_id = ? AND id > ?", runID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// DeleteJobRunEventsBefore deletes the events of runs that started before
+// cutoff. The runs themselves are kept.
+func DeleteJobRunEventsBefore(tx *gorm.DB, cutoff time.Time) (int64, error) {
+	result := tx.Where("job_run_id IN (?)", tx.Model(&JobRun{}).Select("id").Where("started_at < ?", cutoff)).
+		Delete(&JobRunEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecommendationPreferences are the recommendation settings a user chose.
+// Users without a stored row get DefaultRecommendationPreferences.
+type RecommendationPreferences struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	OptedIn          bool `gorm:"not null"`
+	MaxEmailsPerWeek *int
+
+	// Lowercase English weekday names the user prefers to go to the cinema on
+	PreferredDays    []string    `gorm:"type:jsonb;serializer:json"`
+	ExcludedMovieIDs []uuid.UUID `gorm:"type:jsonb;serializer:json"`
+	ExcludedKeywords []string    `gorm:"type:jsonb;serializer:json"`
+}
+
+func DefaultRecommendationPreferences(userID uuid.UUID) RecommendationPreferences {
+	return RecommendationPreferences{
+		UserID:           userID,
+		OptedIn:          true,
+		PreferredDays:    []string{},
+		ExcludedMovieIDs: []uuid.UUID{},
+		ExcludedKeywords: []string{},
+	}
+}
+
+// PreferredWeekdays returns the preferred days as a set. An empty set means
+// any day is fine.
+func (p RecommendationPreferences) PreferredWeekdays() map[time.Weekday]bool {
+	days := make(map[time.Weekday]bool)
+	for _, name := range p.PreferredDays {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(name, day.String()) {
+				days[day] = true
+			}
+		}
+	}
+	return days
+}
+
+func (p *RecommendationPreferences) Save(tx *gorm.DB) error {
+	if err := tx.Save(p).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRecommendationPreferences returns a user's preferences, falling back to
+// the defaults if they never changed them.
+func GetRecommendationPreferences(tx *gorm.DB, userID uuid.UUID) (RecommendationPreferences, error) {
+	var preferences RecommendationPreferences
+	err := tx.Where("user_id = ?", userID).First(&preferences).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultRecommendationPreferences(userID), nil
+	}
+	return preferences, err
+}
+
+// OptOutOfRecommendations turns recommendation emails off for a user and
+// keeps the rest of their preferences.
+func OptOutOfRecommendations(tx *gorm.DB, userID uuid.UUID) error {
+	preferences := DefaultRecommendationPreferences(userID)
+	preferences.OptedIn = false
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"opted_in", "updated_at"}),
+	}).Create(&preferences).Error
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredWeekdays(t *testing.T) {
+	preferences := DefaultRecommendationPreferences(uuid.New())
+	assert.True(t, preferences.OptedIn)
+	assert.Empty(t, preferences.PreferredWeekdays())
+
+	preferences.PreferredDays = []string{"friday", "Saturday", "someday"}
+	assert.Equal(t, map[time.Weekday]bool{time.Friday: true, time.Saturday: true}, preferences.PreferredWeekdays())
+}
@@ -16,8 +16,18 @@ const (
 	StatusOpened  RecommendationStatus = "opened"
 	StatusClicked RecommendationStatus = "clicked"
 	StatusFailed  RecommendationStatus = "failed"
+	StatusDryRun  RecommendationStatus = "dry_run" // Generated by a dry run, never emailed
 )
 
+// Valid reports whether s is one of the known recommendation statuses.
+func (s RecommendationStatus) Valid() bool {
+	switch s {
+	case StatusPending, StatusSent, StatusOpened, StatusClicked, StatusFailed, StatusDryRun:
+		return true
+	}
+	return false
+}
+
 type Recommendation struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	CreatedAt time.Time
@@ -34,12 +44,14 @@ type Recommendation struct {
 	ClickedAt *time.Time
 
 	Status RecommendationStatus `gorm:"type:varchar(50);default:'pending';index"`
+	Tier   string               `gorm:"type:varchar(50);not null"` // Fallback tier of the recommender that picked the movie
 
 	GenerationContext string `gorm:"type:jsonb"` // Store AI context for debugging
 
 	// Email tracking
-	EmailTo      string
-	EmailSubject string
+	EmailTo        string
+	EmailSubject   string
+	ReservationURL string `gorm:"type:text"` // Deep link the click redirect points to
 }
 
 func (r *Recommendation) Create(tx *gorm.DB) error {
@@ -71,16 +83,22 @@ func GetRecommendation(tx *gorm.DB, id uuid.UUID) (Recommendation, error) {
 	return recommendation, nil
 }
 
+// GetRecommendationsByUser returns the recommendations that were delivered to
+// a user, newest first unless another sort is given.
 func GetRecommendationsByUser(tx *gorm.DB, userID uuid.UUID, pagination *request.PaginationOptions, sort *request.SortOptions) ([]Recommendation, int64, error) {
 	var recommendations []Recommendation
 	var total int64
 
-	query := tx.Model(&Recommendation{}).Where("user_id = ?", userID)
+	query := tx.Model(&Recommendation{}).Where("user_id = ? AND status IN ?", userID, deliveredStatuses)
 
 	if err := query.Count(&total).Error; err != nil {
 		return recommendations, 0, err
 	}
 
+	if sort == nil {
+		sort = &request.SortOptions{Column: "created_at", Desc: true}
+	}
+
 	if err := query.Scopes(request.PaginateScope(pagination), request.SortScope(sort)).Find(&recommendations).Error; err != nil {
 		return recommendations, 0, err
 	}
@@ -88,16 +106,20 @@ func GetRecommendationsByUser(tx *gorm.DB, userID uuid.UUID, pagination *request
 	return recommendations, total, nil
 }
 
-func GetRecommendations(tx *gorm.DB, pagination *request.PaginationOptions, sort *request.SortOptions) ([]Recommendation, int64, error) {
+func GetRecommendations(tx *gorm.DB, filters *request.FilterOptions, pagination *request.PaginationOptions, sort *request.SortOptions) ([]Recommendation, int64, error) {
 	var recommendations []Recommendation
 	var total int64
 
-	query := tx.Model(&Recommendation{})
+	query := tx.Model(&Recommendation{}).Scopes(request.FilterScope(filters))
 
 	if err := query.Count(&total).Error; err != nil {
 		return recommendations, 0, err
 	}
 
+	if sort == nil {
+		sort = &request.SortOptions{Column: "created_at", Desc: true}
+	}
+
 	if err := query.Scopes(request.PaginateScope(pagination), request.SortScope(sort)).Find(&recommendations).Error; err != nil {
 		return recommendations, 0, err
 	}
@@ -113,6 +135,72 @@ func MarkRecommendationAsSent(tx *gorm.DB, id uuid.UUID) error {
 	}).Error
 }
 
+// MarkRecommendationAsOpened records the first time a recommendation email
+// was opened. Repeated opens leave the record untouched and the status only
+// moves forward from sent. Dry run recommendations were never emailed, so
+// opening their stored email is not recorded.
+func MarkRecommendationAsOpened(tx *gorm.DB, id uuid.UUID) error {
+	err := tx.Model(&Recommendation{}).
+		Where("id = ? AND opened_at IS NULL AND status <> ?", id, StatusDryRun).
+		Update("opened_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&Recommendation{}).
+		Where("id = ? AND status = ?", id, StatusSent).
+		Update("status", StatusOpened).Error
+}
+
+// MarkRecommendationAsClicked records the first click on a recommendation
+// link. A click implies the email was opened, so OpenedAt is filled in too.
+// Like opens, clicks on dry run recommendations are not recorded.
+func MarkRecommendationAsClicked(tx *gorm.DB, id uuid.UUID) error {
+	now := time.Now()
+
+	err := tx.Model(&Recommendation{}).
+		Where("id = ? AND clicked_at IS NULL AND status <> ?", id, StatusDryRun).
+		Update("clicked_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&Recommendation{}).
+		Where("id = ? AND opened_at IS NULL AND status <> ?", id, StatusDryRun).
+		Update("opened_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&Recommendation{}).
+		Where("id = ? AND status IN ?", id, []RecommendationStatus{StatusSent, StatusOpened}).
+		Update("status", StatusClicked).Error
+}
+
 func MarkRecommendationAsFailed(tx *gorm.DB, id uuid.UUID) error {
 	return tx.Model(&Recommendation{}).Where("id = ?", id).Update("status", StatusFailed).Error
 }
+
+// deliveredStatuses are the statuses of recommendations that reached the user.
+var deliveredStatuses = []RecommendationStatus{StatusSent, StatusOpened, StatusClicked}
+
+// GetRecommendedMovieIDsSince returns the movies delivered to a user since the
+// given time.
+func GetRecommendedMovieIDsSince(tx *gorm.DB, userID uuid.UUID, since time.Time) ([]uuid.UUID, error) {
+	var movieIDs []uuid.UUID
+	err := tx.Model(&Recommendation{}).
+		Where("user_id = ? AND status IN ? AND sent_at >= ?", userID, deliveredStatuses, since).
+		Distinct().
+		Pluck("movie_id", &movieIDs).Error
+	return movieIDs, err
+}
+
+// CountRecommendationsSentSince counts the recommendations delivered to a user
+// since the given time.
+func CountRecommendationsSentSince(tx *gorm.DB, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := tx.Model(&Recommendation{}).
+		Where("user_id = ? AND status IN ? AND sent_at >= ?", userID, deliveredStatuses, since).
+		Count(&count).Error
+	return count, err
+}
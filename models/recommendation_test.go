@@ -34,6 +34,7 @@ func TestRecommendationStatus(t *testing.T) {
 		{"Opened", StatusOpened},
 		{"Clicked", StatusClicked},
 		{"Failed", StatusFailed},
+		{"DryRun", StatusDryRun},
 	}
 
 	for _, tt := range tests {
@@ -43,3 +44,10 @@ func TestRecommendationStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestRecommendationStatusValid(t *testing.T) {
+	assert.True(t, StatusSent.Valid())
+	assert.True(t, StatusFailed.Valid())
+	assert.False(t, RecommendationStatus("bounced").Valid())
+	assert.False(t, RecommendationStatus("").Valid())
+}
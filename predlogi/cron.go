@@ -4,10 +4,15 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/PRPO-skupina-02/predlogi/models"
 	"github.com/go-co-op/gocron/v2"
 	"gorm.io/gorm"
 )
 
+// Both the startup and the scheduled run share this name, so they also share
+// the distributed lock.
+const recommendationJobName = "recommendation"
+
 func SetupCron(db *gorm.DB) error {
 	schedule := os.Getenv("RECOMMENDATION_SCHEDULE")
 	if schedule == "" {
@@ -17,7 +22,23 @@ func SetupCron(db *gorm.DB) error {
 
 	slog.Info("Setting up cron scheduler", "schedule", schedule)
 
-	s, err := gocron.NewScheduler()
+	// Record runs of replicas that died as failed, job runs are otherwise
+	// only reconciled when a replica takes the lock
+	if err := FailOrphanedJobRuns(db); err != nil {
+		slog.Warn("Failed to clean up orphaned job runs", "error", err)
+	}
+
+	locker, err := newJobLocker(db)
+	if err != nil {
+		return err
+	}
+
+	s, err := gocron.NewScheduler(
+		gocron.WithDistributedLocker(locker),
+		gocron.WithGlobalJobOptions(
+			gocron.WithEventListeners(gocron.AfterLockError(logLockError)),
+		),
+	)
 	if err != nil {
 		return err
 	}
@@ -25,7 +46,8 @@ func SetupCron(db *gorm.DB) error {
 	// Run job on startup
 	_, err = s.NewJob(
 		gocron.OneTimeJob(gocron.OneTimeJobStartImmediately()),
-		gocron.NewTask(RunRecommendationJob, db),
+		gocron.NewTask(RunRecommendationJob, db, models.JobTriggerStartup),
+		gocron.WithName(recommendationJobName),
 	)
 	if err != nil {
 		return err
@@ -34,7 +56,8 @@ func SetupCron(db *gorm.DB) error {
 	// Schedule recurring job
 	j, err := s.NewJob(
 		gocron.CronJob(schedule, false),
-		gocron.NewTask(RunRecommendationJob, db),
+		gocron.NewTask(RunRecommendationJob, db, models.JobTriggerCron),
+		gocron.WithName(recommendationJobName),
 	)
 	if err != nil {
 		return err
@@ -42,6 +65,6 @@ func SetupCron(db *gorm.DB) error {
 
 	s.Start()
 
-	slog.Info("Cron job started", "id", j.ID(), "schedule", schedule)
+	slog.Info("Cron job started", "id", j.ID(), "schedule", schedule, "lock_holder", locker.holder)
 	return nil
 }
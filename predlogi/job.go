@@ -2,38 +2,194 @@ package predlogi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/PRPO-skupina-02/predlogi/clients/auth"
 	"github.com/PRPO-skupina-02/predlogi/clients/nakup"
 	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
 	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func RunRecommendationJob(db *gorm.DB) {
-	slog.Info("Starting recommendation generation job")
-	startTime := time.Now()
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-	defer cancel()
+// jobTimeout bounds a single run of the recommendation job.
+const jobTimeout = 30 * time.Minute
 
+// defaultEventRetentionDays is how long progress events of a run are kept
+// unless RECOMMENDATION_EVENT_RETENTION_DAYS says otherwise.
+const defaultEventRetentionDays = 30
+
+// RunRecommendationJob records and executes a run in the foreground. The
+// caller is expected to hold the job lock, which the scheduler takes care of.
+func RunRecommendationJob(db *gorm.DB, trigger models.JobTrigger) {
+	run, err := createJobRun(db, trigger, false)
+	if err != nil {
+		slog.Error("Failed to record job run", "error", err)
+		return
+	}
+
+	executeJobRun(db, run)
+}
+
+// StartRecommendationJob takes the job lock, records a new run and executes
+// it in the background on its own context. It returns ErrLockHeld if another
+// run is already in progress on any replica. A dry run generates and stores
+// recommendations without emailing anyone.
+func StartRecommendationJob(db *gorm.DB, trigger models.JobTrigger, dryRun bool) (*models.JobRun, error) {
+	locker, err := newJobLocker(db)
+	if err != nil {
+		return nil, err
+	}
+	// Manual runs release the lock as soon as they finish, and only wait for
+	// a run that is actually in progress, not for the hold of the last one.
+	locker.hold = 0
+	locker.ignoreHold = true
+
+	lock, err := locker.Lock(context.Background(), recommendationJobName)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := createJobRun(db, trigger, dryRun)
+	if err != nil {
+		_ = lock.Unlock(context.Background())
+		return nil, err
+	}
+
+	go func() {
+		defer func() { _ = lock.Unlock(context.Background()) }()
+		executeJobRun(db, run)
+	}()
+
+	return run, nil
+}
+
+func createJobRun(db *gorm.DB, trigger models.JobTrigger, dryRun bool) (*models.JobRun, error) {
+	// The caller holds the lock, so runs still marked as running were left
+	// behind by a replica that died
+	if failed, err := models.FailInterruptedJobRuns(db); err != nil {
+		slog.Warn("Failed to clean up interrupted job runs", "error", err)
+	} else if failed > 0 {
+		slog.Warn("Marked interrupted job runs as failed", "count", failed)
+	}
+	pruneJobRunEvents(db)
+
+	run := &models.JobRun{
+		TriggerSource: trigger,
+		Status:        models.JobStatusRunning,
+		DryRun:        dryRun,
+		StartedAt:     time.Now(),
+	}
+	if err := run.Create(db); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// pruneJobRunEvents deletes progress events of old runs. A retention of 0
+// days keeps them forever.
+func pruneJobRunEvents(db *gorm.DB) {
+	retentionDays := defaultEventRetentionDays
+	if rd := os.Getenv("RECOMMENDATION_EVENT_RETENTION_DAYS"); rd != "" {
+		if parsed, err := strconv.Atoi(rd); err == nil && parsed >= 0 {
+			retentionDays = parsed
+		}
+	}
+	if retentionDays == 0 {
+		return
+	}
+
+	deleted, err := models.DeleteJobRunEventsBefore(db, time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		slog.Warn("Failed to prune job run events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Info("Pruned job run events", "count", deleted, "retention_days", retentionDays)
+	}
+}
+
+func executeJobRun(db *gorm.DB, run *models.JobRun) {
+	slog.Info("Starting recommendation generation job", "run_id", run.ID, "trigger", run.TriggerSource, "dry_run", run.DryRun)
+	timeoutCtx, cancelTimeout := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancelTimeout()
+	ctx, cancel := context.WithCancelCause(timeoutCtx)
+	defer cancel(nil)
+
+	activeRuns.add(run.ID, cancel)
+	defer activeRuns.remove(run.ID)
+	go watchCancelRequests(ctx, db, run.ID, cancel)
+
+	summary, err := generateRecommendations(ctx, db, run)
+	if cause := context.Cause(ctx); errors.Is(cause, ErrJobCancelled) || errors.Is(cause, ErrLockLost) {
+		err = cause
+	}
+	finishJobRun(db, run, summary, err)
+
+	if errors.Is(err, ErrJobCancelled) {
+		slog.Warn("Recommendation job cancelled", "run_id", run.ID, "duration", time.Since(run.StartedAt))
+		return
+	}
+
+	if err != nil {
+		slog.Error("Recommendation job failed", "run_id", run.ID, "error", err, "duration", time.Since(run.StartedAt))
+		return
+	}
+
+	slog.Info("Recommendation job completed successfully", "run_id", run.ID, "duration", time.Since(run.StartedAt))
+}
+
+func generateRecommendations(ctx context.Context, db *gorm.DB, run *models.JobRun) (*services.GenerationSummary, error) {
+	generator, err := newRecommendationGenerator(db, os.Getenv("RABBITMQ_URL"))
+	if err != nil {
+		return nil, err
+	}
+	defer generator.Close()
+
+	// Closed before the run is finished, so streams see every event
+	progress := newDBProgressReporter(db, run.ID)
+	defer progress.Close()
+	generator.SetProgressReporter(progress)
+	generator.SetDryRun(run.DryRun)
+
+	// Generate recommendations for all users
+	return generator.GenerateForAllUsers(ctx)
+}
+
+// PreviewRecommendation generates a recommendation for a single user without
+// storing or emailing it. See services.RecommendationGenerator.PreviewForUser.
+func PreviewRecommendation(ctx context.Context, db *gorm.DB, userID uuid.UUID) (*services.RecommendationDraft, error) {
+	// No publisher, a preview never sends anything
+	generator, err := newRecommendationGenerator(db, "")
+	if err != nil {
+		return nil, err
+	}
+	defer generator.Close()
+
+	return generator.PreviewForUser(ctx, userID)
+}
+
+func newRecommendationGenerator(db *gorm.DB, rabbitmqURL string) (*services.RecommendationGenerator, error) {
 	// Initialize clients
 	authHost := os.Getenv("AUTH_HOST")
 	nakupHost := os.Getenv("NAKUP_HOST")
 	sporedHost := os.Getenv("SPORED_HOST")
-	rabbitmqURL := os.Getenv("RABBITMQ_URL")
 
 	authClient := auth.NewClient(authHost)
 	nakupClient := nakup.NewClient(nakupHost)
 	sporedClient := spored.NewClient(sporedHost)
 
-	// Initialize OpenAI service
-	openaiService, err := services.NewOpenAIService()
+	// Initialize the configured recommender
+	recommender, err := services.NewRecommenderFromEnv(db)
 	if err != nil {
-		slog.Error("Failed to initialize OpenAI service", "error", err)
-		return
+		slog.Error("Failed to initialize recommender", "error", err)
+		return nil, err
 	}
 
 	// Initialize recommendation generator
@@ -42,20 +198,70 @@ func RunRecommendationJob(db *gorm.DB) {
 		authClient,
 		nakupClient,
 		sporedClient,
-		openaiService,
+		recommender,
 		rabbitmqURL,
 	)
 	if err != nil {
 		slog.Error("Failed to initialize recommendation generator", "error", err)
-		return
+		return nil, err
 	}
-	defer generator.Close()
 
-	// Generate recommendations for all users
-	if err := generator.GenerateForAllUsers(ctx); err != nil {
-		slog.Error("Recommendation job failed", "error", err, "duration", time.Since(startTime))
-		return
+	return generator, nil
+}
+
+// jobRunStats is the shape of the stats column of a job run.
+type jobRunStats struct {
+	SkipReasons map[string]int    `json:"skip_reasons"`
+	Tiers       map[string]int    `json:"tiers"`
+	SporedCache spored.CacheStats `json:"spored_cache"`
+}
+
+// finishJobRun stores the outcome of a run.
+func finishJobRun(db *gorm.DB, run *models.JobRun, summary *services.GenerationSummary, jobErr error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = models.JobStatusSucceeded
+
+	if summary != nil {
+		run.TotalUsers = summary.TotalUsers
+		run.SuccessCount = summary.SuccessCount
+		run.FailureCount = summary.FailureCount
+		run.SkippedCount = summary.SkippedCount
+
+		errorSummary, _ := json.Marshal(summary.Errors)
+		run.ErrorSummary = string(errorSummary)
+
+		stats, _ := json.Marshal(jobRunStats{
+			SkipReasons: summary.SkipReasons,
+			Tiers:       summary.Tiers,
+			SporedCache: summary.SporedCache,
+		})
+		run.Stats = string(stats)
+
+		if summary.Catalog != nil {
+			catalog, _ := json.Marshal(summary.Catalog)
+			catalogJSON := string(catalog)
+			run.Catalog = &catalogJSON
+		}
+	}
+
+	if jobErr != nil {
+		run.Status = models.JobStatusFailed
+		run.Error = jobErr.Error()
+	}
+
+	if errors.Is(jobErr, ErrJobCancelled) {
+		run.Status = models.JobStatusCancelled
 	}
 
-	slog.Info("Recommendation job completed successfully", "duration", time.Since(startTime))
+	if run.ErrorSummary == "" {
+		run.ErrorSummary = "[]"
+	}
+	if run.Stats == "" {
+		run.Stats = "{}"
+	}
+
+	if err := run.Finish(db); err != nil {
+		slog.Error("Failed to save job run", "run_id", run.ID, "error", err)
+	}
 }
@@ -0,0 +1,134 @@
+package predlogi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrLockHeld = errors.New("job lock is held by another replica")
+
+// The lease is short and renewed while the job runs, so it outlasts runs
+// that overrun jobTimeout but frees up soon after a replica dies.
+const (
+	lockTTL           = 2 * time.Minute
+	lockRenewInterval = 30 * time.Second
+)
+
+// jobLocker implements gocron.Locker on top of the job_locks table so that
+// only one replica runs a given job.
+type jobLocker struct {
+	db     *gorm.DB
+	holder string
+	ttl    time.Duration
+	hold   time.Duration
+	// ignoreHold takes over leases that were released but are still held,
+	// so the hold only staggers scheduled runs.
+	ignoreHold bool
+}
+
+type jobLock struct {
+	locker    *jobLocker
+	key       string
+	stopRenew context.CancelFunc
+}
+
+func newJobLocker(db *gorm.DB) (*jobLocker, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "predlogi"
+	}
+
+	hold := 10 * time.Minute
+	if h := os.Getenv("RECOMMENDATION_LOCK_HOLD"); h != "" {
+		hold, err = time.ParseDuration(h)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RECOMMENDATION_LOCK_HOLD: %w", err)
+		}
+	}
+
+	return &jobLocker{
+		db:     db,
+		holder: fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8]),
+		ttl:    lockTTL,
+		hold:   hold,
+	}, nil
+}
+
+func (l *jobLocker) Lock(ctx context.Context, key string) (gocron.Lock, error) {
+	acquired, err := models.AcquireJobLock(l.db.WithContext(ctx), key, l.holder, l.ttl, l.ignoreHold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire job lock: %w", err)
+	}
+	if !acquired {
+		return nil, ErrLockHeld
+	}
+
+	slog.Info("Acquired job lock", "job", key, "holder", l.holder)
+
+	renewCtx, stopRenew := context.WithCancel(context.Background())
+	go l.renew(renewCtx, key)
+
+	return &jobLock{locker: l, key: key, stopRenew: stopRenew}, nil
+}
+
+// renew keeps the lease on key alive until ctx is done. Once the lease is
+// lost, either taken over or not renewed before it expired, another replica
+// may start a run, so the runs on this replica are cancelled.
+func (l *jobLocker) renew(ctx context.Context, key string) {
+	ticker := time.NewTicker(lockRenewInterval)
+	defer ticker.Stop()
+
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := models.RenewJobLock(l.db.WithContext(ctx), key, l.holder, l.ttl)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				slog.Warn("Failed to renew job lock", "job", key, "holder", l.holder, "error", err)
+				if time.Since(renewedAt) < l.ttl {
+					continue
+				}
+			} else if renewed {
+				renewedAt = time.Now()
+				continue
+			}
+
+			cancelled := activeRuns.cancelAll(ErrLockLost)
+			slog.Error("Lost job lock while the job is running", "job", key, "holder", l.holder, "cancelled_runs", cancelled)
+			return
+		}
+	}
+}
+
+func (l *jobLock) Unlock(ctx context.Context) error {
+	l.stopRenew()
+
+	err := models.ReleaseJobLock(l.locker.db.WithContext(ctx), l.key, l.locker.holder, l.locker.hold)
+	if err != nil {
+		slog.Error("Failed to release job lock", "job", l.key, "holder", l.locker.holder, "error", err)
+		return err
+	}
+	return nil
+}
+
+func logLockError(jobID uuid.UUID, jobName string, err error) {
+	if errors.Is(err, ErrLockHeld) {
+		slog.Info("Skipping job, another replica is running it", "job", jobName, "job_id", jobID)
+		return
+	}
+	slog.Error("Skipping job, could not acquire lock", "job", jobName, "job_id", jobID, "error", err)
+}
@@ -0,0 +1,71 @@
+package predlogi
+
+import (
+	"log/slog"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/PRPO-skupina-02/predlogi/services"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// progressBufferSize is how many events workers can report before they wait
+// for the writer.
+const progressBufferSize = 256
+
+// dbProgressReporter stores progress events of a run in the database, so they
+// can be streamed from any replica. A single writer stores the events one at
+// a time, so their IDs are committed in order and streams that resume after
+// an ID never miss an event.
+type dbProgressReporter struct {
+	db     *gorm.DB
+	runID  uuid.UUID
+	events chan services.ProgressEvent
+	done   chan struct{}
+}
+
+func newDBProgressReporter(db *gorm.DB, runID uuid.UUID) *dbProgressReporter {
+	r := &dbProgressReporter{
+		db:     db,
+		runID:  runID,
+		events: make(chan services.ProgressEvent, progressBufferSize),
+		done:   make(chan struct{}),
+	}
+	go r.write()
+	return r
+}
+
+func (r *dbProgressReporter) Report(event services.ProgressEvent) {
+	r.events <- event
+}
+
+// Close stores the remaining events. Report must not be called afterwards.
+func (r *dbProgressReporter) Close() {
+	close(r.events)
+	<-r.done
+}
+
+func (r *dbProgressReporter) write() {
+	defer close(r.done)
+
+	for event := range r.events {
+		record := models.JobRunEvent{
+			JobRunID:   r.runID,
+			Type:       string(event.Type),
+			UserIndex:  event.UserIndex,
+			TotalUsers: event.TotalUsers,
+			MovieTitle: event.MovieTitle,
+			Message:    event.Error,
+		}
+		if event.UserID != uuid.Nil {
+			record.UserID = &event.UserID
+		}
+		if event.MovieID != uuid.Nil {
+			record.MovieID = &event.MovieID
+		}
+
+		if err := record.Create(r.db); err != nil {
+			slog.Warn("Failed to store job run event", "run_id", r.runID, "type", event.Type, "error", err)
+		}
+	}
+}
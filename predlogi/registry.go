@@ -0,0 +1,160 @@
+package predlogi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobCancelled  = errors.New("job was cancelled")
+	ErrJobNotRunning = errors.New("job run is not in progress")
+	ErrLockLost      = errors.New("job lock was lost, another replica may have started a run")
+)
+
+// cancelPollInterval is how often a running job checks whether a cancel was
+// requested through another replica.
+const cancelPollInterval = 5 * time.Second
+
+// runRegistry tracks the cancel functions of runs executing on this replica.
+type runRegistry struct {
+	mu      sync.Mutex
+	cancels map[uuid.UUID]context.CancelCauseFunc
+}
+
+var activeRuns = &runRegistry{
+	cancels: make(map[uuid.UUID]context.CancelCauseFunc),
+}
+
+func (r *runRegistry) add(id uuid.UUID, cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[id] = cancel
+}
+
+func (r *runRegistry) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, id)
+}
+
+func (r *runRegistry) cancel(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cancel, ok := r.cancels[id]
+	if ok {
+		cancel(ErrJobCancelled)
+	}
+	return ok
+}
+
+// cancelAll cancels every run on this replica with cause.
+func (r *runRegistry) cancelAll(cause error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cancel := range r.cancels {
+		cancel(cause)
+	}
+	return len(r.cancels)
+}
+
+// CancelRecommendationJob stops a running job. Runs on this replica are
+// cancelled directly; runs elsewhere are flagged in the database and stopped
+// by their own replica on its next poll.
+func CancelRecommendationJob(tx *gorm.DB, id uuid.UUID) (models.JobRun, error) {
+	if err := FailOrphanedJobRuns(tx); err != nil {
+		return models.JobRun{}, err
+	}
+
+	run, err := models.GetJobRun(tx, id)
+	if err != nil {
+		return run, err
+	}
+
+	if run.Status != models.JobStatusRunning {
+		return run, ErrJobNotRunning
+	}
+
+	if err := models.RequestJobRunCancel(tx, id); err != nil {
+		return run, err
+	}
+
+	if activeRuns.cancel(id) {
+		slog.Info("Cancelled job run", "run_id", id)
+	} else {
+		slog.Info("Requested cancellation of job run on another replica", "run_id", id)
+	}
+
+	return models.GetJobRun(tx, id)
+}
+
+// FailOrphanedJobRuns marks runs as failed that are still running although no
+// replica holds the job lock, so readers do not wait for them forever.
+func FailOrphanedJobRuns(tx *gorm.DB) error {
+	failed, err := models.FailOrphanedJobRuns(tx, recommendationJobName)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		slog.Warn("Marked orphaned job runs as failed", "count", failed)
+	}
+	return nil
+}
+
+// ShowOrphanedJobRuns returns runs with those still running although no
+// replica holds the job lock shown as failed. Unlike FailOrphanedJobRuns it
+// does not write, the runs are only recorded as failed by the next replica
+// that starts or takes the lock.
+func ShowOrphanedJobRuns(tx *gorm.DB, runs []models.JobRun) ([]models.JobRun, error) {
+	if !slices.ContainsFunc(runs, func(run models.JobRun) bool { return run.Status == models.JobStatusRunning }) {
+		return runs, nil
+	}
+
+	live, err := models.HasLiveJobLock(tx, recommendationJobName)
+	if err != nil || live {
+		return runs, err
+	}
+
+	shown := make([]models.JobRun, 0, len(runs))
+	for _, run := range runs {
+		if run.Status == models.JobStatusRunning {
+			run = run.AsInterrupted()
+		}
+		shown = append(shown, run)
+	}
+	return shown, nil
+}
+
+// watchCancelRequests cancels the run once a cancel request shows up in the
+// database. It returns when ctx is done.
+func watchCancelRequests(ctx context.Context, db *gorm.DB, id uuid.UUID, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			requested, err := models.IsJobRunCancelRequested(db, id)
+			if err != nil {
+				slog.Warn("Failed to check job run for cancel requests", "run_id", id, "error", err)
+				continue
+			}
+			if requested {
+				slog.Info("Cancel requested for job run", "run_id", id)
+				cancel(ErrJobCancelled)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/auth"
+	"github.com/google/uuid"
+)
+
+// Audience rules a user can be excluded by. They are reported as skip
+// reasons, so the job summary counts exclusions per rule.
+const (
+	AudienceRole            = "audience_role"
+	AudienceAllowList       = "audience_allow_list"
+	AudienceDenyList        = "audience_deny_list"
+	AudienceAccountAge      = "audience_account_age"
+	AudienceMinReservations = "audience_min_reservations"
+)
+
+// audienceRules restrict which users receive recommendations at all.
+type audienceRules struct {
+	roles           map[auth.UserRole]bool
+	allow           map[uuid.UUID]bool // empty allows everyone
+	deny            map[uuid.UUID]bool
+	minAccountAge   time.Duration
+	minReservations int
+}
+
+func newAudienceRulesFromEnv() (audienceRules, error) {
+	rules := audienceRules{
+		roles: map[auth.UserRole]bool{auth.RoleCustomer: true},
+	}
+
+	if r := os.Getenv("RECOMMENDATION_AUDIENCE_ROLES"); r != "" {
+		rules.roles = make(map[auth.UserRole]bool)
+		for _, role := range splitList(r) {
+			switch auth.UserRole(role) {
+			case auth.RoleCustomer, auth.RoleEmployee, auth.RoleAdmin:
+				rules.roles[auth.UserRole(role)] = true
+			default:
+				return rules, fmt.Errorf("invalid RECOMMENDATION_AUDIENCE_ROLES role %q", role)
+			}
+		}
+	}
+
+	var err error
+	if rules.allow, err = getUUIDListEnv("RECOMMENDATION_AUDIENCE_ALLOW_USERS"); err != nil {
+		return rules, err
+	}
+	if rules.deny, err = getUUIDListEnv("RECOMMENDATION_AUDIENCE_DENY_USERS"); err != nil {
+		return rules, err
+	}
+
+	days, err := getNonNegativeIntEnv("RECOMMENDATION_AUDIENCE_MIN_ACCOUNT_AGE_DAYS", 0)
+	if err != nil {
+		return rules, err
+	}
+	rules.minAccountAge = time.Duration(days) * 24 * time.Hour
+
+	rules.minReservations, err = getNonNegativeIntEnv("RECOMMENDATION_AUDIENCE_MIN_RESERVATIONS", 0)
+	if err != nil {
+		return rules, err
+	}
+
+	return rules, nil
+}
+
+// excludeUser returns the rule that excludes user based on their account,
+// or an empty string if none does.
+func (r audienceRules) excludeUser(user *auth.User, now time.Time) string {
+	switch {
+	case r.deny[user.ID]:
+		return AudienceDenyList
+	case len(r.allow) > 0 && !r.allow[user.ID]:
+		return AudienceAllowList
+	case !r.roles[user.Role]:
+		return AudienceRole
+	case r.minAccountAge > 0 && now.Sub(user.CreatedAt) < r.minAccountAge:
+		return AudienceAccountAge
+	}
+	return ""
+}
+
+// excludeActivity returns the rule that excludes a user with the given number
+// of past reservations, or an empty string if none does.
+func (r audienceRules) excludeActivity(pastReservations int) string {
+	if pastReservations < r.minReservations {
+		return AudienceMinReservations
+	}
+	return ""
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getUUIDListEnv(key string) (map[uuid.UUID]bool, error) {
+	ids := make(map[uuid.UUID]bool)
+	for _, item := range splitList(os.Getenv(key)) {
+		id, err := uuid.Parse(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s user ID %q", key, item)
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
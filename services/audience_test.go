@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudienceRules(t *testing.T) {
+	now := time.Now()
+	customer := &auth.User{ID: uuid.New(), Role: auth.RoleCustomer, CreatedAt: now.AddDate(-1, 0, 0)}
+	newcomer := &auth.User{ID: uuid.New(), Role: auth.RoleCustomer, CreatedAt: now.AddDate(0, 0, -2)}
+	admin := &auth.User{ID: uuid.New(), Role: auth.RoleAdmin, CreatedAt: now.AddDate(-1, 0, 0)}
+
+	rules := audienceRules{
+		roles:         map[auth.UserRole]bool{auth.RoleCustomer: true},
+		deny:          map[uuid.UUID]bool{},
+		minAccountAge: 7 * 24 * time.Hour,
+	}
+
+	assert.Equal(t, "", rules.excludeUser(customer, now))
+	assert.Equal(t, AudienceAccountAge, rules.excludeUser(newcomer, now))
+	assert.Equal(t, AudienceRole, rules.excludeUser(admin, now))
+
+	rules.deny[customer.ID] = true
+	assert.Equal(t, AudienceDenyList, rules.excludeUser(customer, now))
+
+	rules.allow = map[uuid.UUID]bool{admin.ID: true}
+	assert.Equal(t, AudienceAllowList, rules.excludeUser(newcomer, now))
+
+	rules.minReservations = 2
+	assert.Equal(t, AudienceMinReservations, rules.excludeActivity(1))
+	assert.Equal(t, "", rules.excludeActivity(2))
+}
+
+func TestNewAudienceRulesFromEnv(t *testing.T) {
+	id := uuid.New()
+	t.Setenv("RECOMMENDATION_AUDIENCE_ROLES", "customer, employee")
+	t.Setenv("RECOMMENDATION_AUDIENCE_DENY_USERS", id.String())
+
+	rules, err := newAudienceRulesFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, map[auth.UserRole]bool{auth.RoleCustomer: true, auth.RoleEmployee: true}, rules.roles)
+	assert.True(t, rules.deny[id])
+
+	t.Setenv("RECOMMENDATION_AUDIENCE_ROLES", "guest")
+	_, err = newAudienceRulesFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/nakup"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/google/uuid"
+)
+
+// Catalog is a snapshot of the upcoming schedule. It is built once per run
+// and shared by every user processed in that run.
+type Catalog struct {
+	StartDate time.Time                   `json:"start_date"`
+	EndDate   time.Time                   `json:"end_date"`
+	TimeSlots []spored.TimeSlot           `json:"time_slots"`
+	Movies    []UpcomingMovie             `json:"movies"`
+	MovieByID map[uuid.UUID]*spored.Movie `json:"-"`
+}
+
+// GenerationRun holds the state shared by every user processed in one run.
+type GenerationRun struct {
+	Catalog *Catalog
+	Spored  *spored.CachedClient
+
+	// Reservations loaded ahead of generation, by user ID
+	reservations sync.Map
+}
+
+// userReservations returns the reservations of a user, taking the ones loaded
+// earlier in the run if there are any.
+func (r *GenerationRun) userReservations(source ReservationSource, userID uuid.UUID) ([]nakup.Reservation, error) {
+	if reservations, ok := r.reservations.LoadAndDelete(userID); ok {
+		return reservations.([]nakup.Reservation), nil
+	}
+	return source.GetUserReservations(userID)
+}
+
+// NewGenerationRun snapshots the upcoming schedule and sets up run-scoped
+// caches.
+func (rg *RecommendationGenerator) NewGenerationRun() (*GenerationRun, error) {
+	catalog, err := rg.BuildCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerationRun{
+		Catalog: catalog,
+		Spored:  spored.NewCachedClient(rg.sporedClient),
+	}, nil
+}
+
+// BuildCatalog fetches the schedule for the lookahead window and extracts
+// the unique upcoming movies.
+func (rg *RecommendationGenerator) BuildCatalog() (*Catalog, error) {
+	startDate := time.Now()
+	endDate := startDate.AddDate(0, 0, rg.lookaheadDays)
+
+	timeSlots, err := rg.sporedClient.GetUpcomingTimeSlots(startDate, endDate)
+	if err != nil {
+		slog.Error("Failed to fetch upcoming schedule", "error", err)
+		return nil, fmt.Errorf("failed to fetch upcoming schedule: %w", err)
+	}
+
+	slog.Info("Fetched upcoming timeslots", "count", len(timeSlots))
+
+	catalog := &Catalog{
+		StartDate: startDate,
+		EndDate:   endDate,
+		TimeSlots: timeSlots,
+		Movies:    []UpcomingMovie{},
+		MovieByID: make(map[uuid.UUID]*spored.Movie),
+	}
+
+	for i := range timeSlots {
+		timeSlot := &timeSlots[i]
+		if _, exists := catalog.MovieByID[timeSlot.MovieID]; exists {
+			continue
+		}
+
+		catalog.MovieByID[timeSlot.MovieID] = &timeSlot.Movie
+		catalog.Movies = append(catalog.Movies, UpcomingMovie{
+			ID:          timeSlot.MovieID.String(),
+			Title:       timeSlot.Movie.Title,
+			Description: timeSlot.Movie.Description,
+			Rating:      timeSlot.Movie.Rating,
+		})
+	}
+
+	slog.Info("Extracted upcoming movies", "count", len(catalog.Movies))
+
+	return catalog, nil
+}
+
+// NextTimeSlotOn returns the earliest screening of a movie starting after the
+// given time on one of the given weekdays, or nil if there is none in the
+// catalog. An empty set allows every day.
+func (c *Catalog) NextTimeSlotOn(movieID uuid.UUID, after time.Time, days map[time.Weekday]bool) *spored.TimeSlot {
+	var next *spored.TimeSlot
+	for i := range c.TimeSlots {
+		timeSlot := &c.TimeSlots[i]
+		if timeSlot.MovieID != movieID || !timeSlot.StartTime.After(after) {
+			continue
+		}
+		if len(days) > 0 && !days[timeSlot.StartTime.Weekday()] {
+			continue
+		}
+		if next == nil || timeSlot.StartTime.Before(next.StartTime) {
+			next = timeSlot
+		}
+	}
+	return next
+}
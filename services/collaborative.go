@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errNoCooccurrence is returned when no candidate was ever booked together
+// with a movie from the user's history.
+var errNoCooccurrence = errors.New("no co-occurrence data for the user's history")
+
+// HistoryObserver is implemented by recommenders that learn from booking
+// histories. Before each run the generator passes them the full history of
+// every active user, which replaces what was observed for the user before.
+type HistoryObserver interface {
+	ObserveHistory(ctx context.Context, userID uuid.UUID, movieIDs []uuid.UUID) error
+}
+
+// CollaborativeRecommender ranks candidates by how often users who booked a
+// movie from the user's history also booked the candidate. The co-occurrence
+// matrix is stored in the database and follows the bookings the job sees.
+type CollaborativeRecommender struct {
+	db *gorm.DB
+}
+
+func NewCollaborativeRecommender(db *gorm.DB) *CollaborativeRecommender {
+	return &CollaborativeRecommender{db: db}
+}
+
+// ObserveHistory implements HistoryObserver by syncing the user's bookings
+// into the co-occurrence matrix.
+func (r *CollaborativeRecommender) ObserveHistory(ctx context.Context, userID uuid.UUID, movieIDs []uuid.UUID) error {
+	return models.SetUserBookings(r.db.WithContext(ctx), userID, movieIDs)
+}
+
+// Recommend implements Recommender. Candidates that were never booked
+// together with the user's history are left out.
+func (r *CollaborativeRecommender) Recommend(ctx context.Context, req RecommendationRequest) (*RecommendationResult, error) {
+	result := &RecommendationResult{}
+	if len(req.UpcomingMovies) == 0 {
+		return result, fmt.Errorf("no upcoming movies available")
+	}
+
+	historyIDs := movieIDs(req.UserHistory, func(m MovieHistory) string { return m.ID })
+	candidateIDs := movieIDs(req.UpcomingMovies, func(m UpcomingMovie) string { return m.ID })
+	if len(historyIDs) == 0 {
+		return result, errNoCooccurrence
+	}
+
+	tx := r.db.WithContext(ctx)
+	cells, err := models.GetMovieCooccurrences(tx, historyIDs, candidateIDs)
+	if err != nil {
+		return result, fmt.Errorf("failed to load co-occurrences: %w", err)
+	}
+	popularity, err := models.GetMoviePopularity(tx, append(historyIDs, candidateIDs...))
+	if err != nil {
+		return result, fmt.Errorf("failed to load movie popularity: %w", err)
+	}
+
+	scores := scoreCooccurrences(req, cells, popularity)
+	if len(scores) == 0 {
+		return result, errNoCooccurrence
+	}
+
+	for _, s := range scores {
+		result.Recommendations = append(result.Recommendations, RecommendationResponse{
+			MovieID:         s.movie.ID,
+			MovieTitle:      s.movie.Title,
+			Reason:          fmt.Sprintf("People who booked %s also booked %s.", s.closest, s.movie.Title),
+			ConfidenceScore: math.Round(s.confidence*100) / 100,
+		})
+	}
+
+	return result, nil
+}
+
+type cooccurrenceScore struct {
+	movie      UpcomingMovie
+	score      float64
+	confidence float64
+	closest    string
+}
+
+// scoreCooccurrences ranks the candidates by the summed cosine similarity
+// between them and the movies in the user's history, where a movie is the
+// set of users that booked it. The confidence is the strongest single
+// similarity, which stays between 0 and 1.
+func scoreCooccurrences(req RecommendationRequest, cells []models.MovieCooccurrence, popularity map[uuid.UUID]int) []cooccurrenceScore {
+	titles := make(map[uuid.UUID]string, len(req.UserHistory))
+	for _, movie := range req.UserHistory {
+		if id, err := uuid.Parse(movie.ID); err == nil {
+			titles[id] = movie.Title
+		}
+	}
+
+	byCandidate := make(map[uuid.UUID]*cooccurrenceScore)
+	for _, movie := range req.UpcomingMovies {
+		if id, err := uuid.Parse(movie.ID); err == nil {
+			byCandidate[id] = &cooccurrenceScore{movie: movie}
+		}
+	}
+
+	for _, cell := range cells {
+		s, ok := byCandidate[cell.MovieB]
+		title, watched := titles[cell.MovieA]
+		if !ok || !watched || cell.MovieA == cell.MovieB {
+			continue
+		}
+
+		norm := math.Sqrt(float64(popularity[cell.MovieA]) * float64(popularity[cell.MovieB]))
+		if norm == 0 || cell.Count <= 0 {
+			continue
+		}
+
+		similarity := math.Min(float64(cell.Count)/norm, 1)
+		s.score += similarity
+		if similarity > s.confidence {
+			s.confidence = similarity
+			s.closest = title
+		}
+	}
+
+	scores := []cooccurrenceScore{}
+	for _, movie := range req.UpcomingMovies {
+		id, err := uuid.Parse(movie.ID)
+		if err != nil {
+			continue
+		}
+		if s := byCandidate[id]; s.score > 0 {
+			scores = append(scores, *s)
+		}
+	}
+
+	sort.SliceStable(scores, func(a, b int) bool {
+		return scores[a].score > scores[b].score
+	})
+
+	return scores
+}
+
+func movieIDs[T any](movies []T, id func(T) string) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(movies))
+	for _, movie := range movies {
+		parsed, err := uuid.Parse(id(movie))
+		if err != nil {
+			slog.Warn("Skipping movie with invalid ID", "movie_id", id(movie))
+			continue
+		}
+		ids = append(ids, parsed)
+	}
+	return ids
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreCooccurrences(t *testing.T) {
+	watched := uuid.New()
+	niche := uuid.New()
+	blockbuster := uuid.New()
+	unrelated := uuid.New()
+
+	req := RecommendationRequest{
+		UserHistory: []MovieHistory{{ID: watched.String(), Title: "Watched"}},
+		UpcomingMovies: []UpcomingMovie{
+			{ID: blockbuster.String(), Title: "Blockbuster"},
+			{ID: niche.String(), Title: "Niche"},
+			{ID: unrelated.String(), Title: "Unrelated"},
+		},
+	}
+	cells := []models.MovieCooccurrence{
+		{MovieA: watched, MovieB: niche, Count: 4},
+		{MovieA: watched, MovieB: blockbuster, Count: 5},
+	}
+	popularity := map[uuid.UUID]int{watched: 10, niche: 4, blockbuster: 100}
+
+	scores := scoreCooccurrences(req, cells, popularity)
+	require.Len(t, scores, 2)
+
+	// Booked together less often, but by a larger share of its audience
+	assert.Equal(t, "Niche", scores[0].movie.Title)
+	assert.Equal(t, "Watched", scores[0].closest)
+	assert.InDelta(t, 4/math.Sqrt(10*4), scores[0].confidence, 0.0001)
+	assert.Equal(t, "Blockbuster", scores[1].movie.Title)
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// contentRatingWeight is how much a candidate's rating counts towards its
+// score, the rest comes from similarity to the user's history.
+const contentRatingWeight = 0.2
+
+// contentTitleWeight repeats title terms so they count more than terms from
+// the description.
+const contentTitleWeight = 2
+
+// stopWords are common English words that say nothing about a movie.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "that": true,
+	"this": true, "his": true, "her": true, "their": true, "they": true, "who": true,
+	"are": true, "was": true, "were": true, "has": true, "have": true, "into": true,
+	"when": true, "where": true, "what": true, "will": true, "one": true, "but": true,
+	"not": true, "all": true, "its": true, "about": true, "after": true, "must": true,
+}
+
+// ContentRecommender ranks candidates by how similar their title and
+// description are to the movies the user watched, using TF-IDF vectors, and
+// by their rating. It runs entirely locally.
+type ContentRecommender struct{}
+
+func NewContentRecommender() *ContentRecommender {
+	return &ContentRecommender{}
+}
+
+type contentScore struct {
+	movie   UpcomingMovie
+	score   float64
+	closest string
+}
+
+// Recommend implements Recommender.
+func (r *ContentRecommender) Recommend(ctx context.Context, req RecommendationRequest) (*RecommendationResult, error) {
+	result := &RecommendationResult{}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if len(req.UpcomingMovies) == 0 {
+		return result, fmt.Errorf("no upcoming movies available")
+	}
+
+	documents := make([][]string, 0, len(req.UserHistory)+len(req.UpcomingMovies))
+	for _, movie := range req.UserHistory {
+		documents = append(documents, movieTerms(movie.Title, movie.Description))
+	}
+	for _, movie := range req.UpcomingMovies {
+		documents = append(documents, movieTerms(movie.Title, movie.Description))
+	}
+
+	idf := inverseDocumentFrequency(documents)
+	vectors := make([]map[string]float64, len(documents))
+	for i, terms := range documents {
+		vectors[i] = tfidfVector(terms, idf)
+	}
+
+	history := vectors[:len(req.UserHistory)]
+	candidates := vectors[len(req.UserHistory):]
+
+	scores := make([]contentScore, len(req.UpcomingMovies))
+	for i, movie := range req.UpcomingMovies {
+		best := 0.0
+		closest := ""
+		for j, watched := range history {
+			if similarity := cosineSimilarity(candidates[i], watched); similarity > best {
+				best = similarity
+				closest = req.UserHistory[j].Title
+			}
+		}
+
+		rating := math.Max(0, math.Min(movie.Rating/10, 1))
+		scores[i] = contentScore{
+			movie:   movie,
+			score:   (1-contentRatingWeight)*best + contentRatingWeight*rating,
+			closest: closest,
+		}
+	}
+
+	sort.SliceStable(scores, func(a, b int) bool {
+		return scores[a].score > scores[b].score
+	})
+
+	for _, s := range scores {
+		result.Recommendations = append(result.Recommendations, RecommendationResponse{
+			MovieID:         s.movie.ID,
+			MovieTitle:      s.movie.Title,
+			Reason:          contentReason(s),
+			ConfidenceScore: math.Round(s.score*100) / 100,
+		})
+	}
+
+	return result, nil
+}
+
+func contentReason(s contentScore) string {
+	if s.closest == "" {
+		return fmt.Sprintf("%s is one of the best rated movies showing soon, with a rating of %.1f/10.", s.movie.Title, s.movie.Rating)
+	}
+	return fmt.Sprintf("Because you watched %s, we think you will enjoy %s.", s.closest, s.movie.Title)
+}
+
+// movieTerms splits a movie's title and description into lowercase terms.
+func movieTerms(title, description string) []string {
+	terms := []string{}
+	titleTerms := tokenize(title)
+	for range contentTitleWeight {
+		terms = append(terms, titleTerms...)
+	}
+	return append(terms, tokenize(description)...)
+}
+
+func tokenize(text string) []string {
+	terms := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < 3 || stopWords[word] {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// inverseDocumentFrequency uses smoothed IDF, so terms that appear in every
+// document still carry a little weight.
+func inverseDocumentFrequency(documents [][]string) map[string]float64 {
+	frequency := make(map[string]int)
+	for _, terms := range documents {
+		seen := make(map[string]bool)
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				frequency[term]++
+			}
+		}
+	}
+
+	n := float64(len(documents))
+	idf := make(map[string]float64, len(frequency))
+	for term, df := range frequency {
+		idf[term] = math.Log((1+n)/(1+float64(df))) + 1
+	}
+	return idf
+}
+
+// tfidfVector returns the L2 normalized TF-IDF vector of a document.
+func tfidfVector(terms []string, idf map[string]float64) map[string]float64 {
+	vector := make(map[string]float64)
+	for _, term := range terms {
+		vector[term]++
+	}
+
+	norm := 0.0
+	for term, tf := range vector {
+		vector[term] = tf * idf[term]
+		norm += vector[term] * vector[term]
+	}
+
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for term := range vector {
+		vector[term] /= norm
+	}
+	return vector
+}
+
+// cosineSimilarity of two normalized vectors.
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	similarity := 0.0
+	for term, weight := range a {
+		similarity += weight * b[term]
+	}
+	return similarity
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentRecommender(t *testing.T) {
+	req := RecommendationRequest{
+		UserHistory: []MovieHistory{
+			{Title: "Space Odyssey", Description: "Astronauts travel through space to a distant planet", Rating: 8.5},
+			{Title: "Romantic Paris", Description: "Two strangers fall in love in Paris", Rating: 6.0},
+		},
+		UpcomingMovies: []UpcomingMovie{
+			{ID: "cooking", Title: "Kitchen Wars", Description: "Chefs compete in a cooking contest", Rating: 7.0},
+			{ID: "space", Title: "Return to Space", Description: "A crew of astronauts returns to the distant planet", Rating: 6.5},
+		},
+	}
+
+	result, err := NewContentRecommender().Recommend(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, result.Recommendations, 2)
+
+	best := result.Best()
+	assert.Equal(t, "space", best.MovieID)
+	assert.Contains(t, best.Reason, "Space Odyssey")
+	assert.Greater(t, best.ConfidenceScore, result.Recommendations[1].ConfidenceScore)
+	assert.LessOrEqual(t, best.ConfidenceScore, 1.0)
+}
+
+func TestContentRecommenderWithoutHistory(t *testing.T) {
+	req := RecommendationRequest{
+		UpcomingMovies: []UpcomingMovie{
+			{ID: "low", Title: "Low", Rating: 4.0},
+			{ID: "high", Title: "High", Rating: 9.0},
+		},
+	}
+
+	result, err := NewContentRecommender().Recommend(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "high", result.Best().MovieID)
+	assert.Contains(t, result.Best().Reason, "best rated")
+}
+
+func TestContentRecommenderNeedsCandidates(t *testing.T) {
+	_, err := NewContentRecommender().Recommend(context.Background(), RecommendationRequest{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/auth"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+)
+
+// RecommendationDraft is everything generated for a user before it is stored
+// or emailed. It is filled in as generation progresses, so the draft of a
+// skipped or failed user shows how far generation got.
+type RecommendationDraft struct {
+	RecommendationID uuid.UUID               `json:"recommendation_id"`
+	UserID           uuid.UUID               `json:"user_id"`
+	History          []MovieHistory          `json:"history"`
+	Candidates       []UpcomingMovie         `json:"candidates"`
+	ExcludedMovies   []string                `json:"excluded_movies"`
+	LikedMovies      []string                `json:"liked_movies"`
+	DislikedMovies   []string                `json:"disliked_movies"`
+	Prompt           string                  `json:"prompt"`
+	RawOutput        string                  `json:"raw_output"`
+	Tier             string                  `json:"tier"`
+	Response         *RecommendationResponse `json:"response"`
+	Movie            *spored.Movie           `json:"movie"`
+	ReservationURL   string                  `json:"reservation_url"`
+	EmailSubject     string                  `json:"email_subject"`
+	EmailData        map[string]interface{}  `json:"email_data"`
+}
+
+// draftRecommendation decides what to recommend to a user and prepares the
+// email, without storing or sending anything.
+func (rg *RecommendationGenerator) draftRecommendation(ctx context.Context, run *GenerationRun, user *auth.User, draft *RecommendationDraft) error {
+	now := time.Now()
+
+	// The ID is assigned up front so it can be part of the links in the email
+	draft.RecommendationID = uuid.New()
+	draft.UserID = user.ID
+
+	// 0. Check the user is part of the audience, then respect their
+	// preferences and the email frequency cap
+	if rule := rg.audience.excludeUser(user, now); rule != "" {
+		slog.Info("User excluded by audience rules", "user_id", user.ID, "rule", rule)
+		return &SkipError{Reason: rule}
+	}
+
+	preferences, err := rg.store.GetPreferences(user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch preferences: %w", err)
+	}
+	if !preferences.OptedIn {
+		slog.Info("User opted out of recommendations", "user_id", user.ID)
+		return &SkipError{Reason: SkipOptedOut}
+	}
+	if preferences.MaxEmailsPerWeek != nil {
+		sent, err := rg.store.CountSentSince(user.ID, now.AddDate(0, 0, -7))
+		if err != nil {
+			return fmt.Errorf("failed to count recent recommendations: %w", err)
+		}
+		if sent >= int64(*preferences.MaxEmailsPerWeek) {
+			slog.Info("User reached their own email frequency cap", "user_id", user.ID, "sent", sent)
+			return &SkipError{Reason: SkipUserFrequencyCap}
+		}
+	}
+
+	if rg.delivery.maxEmails > 0 {
+		sent, err := rg.store.CountSentSince(user.ID, now.Add(-rg.delivery.window))
+		if err != nil {
+			return fmt.Errorf("failed to count recent recommendations: %w", err)
+		}
+		if sent >= int64(rg.delivery.maxEmails) {
+			slog.Info("User reached the email frequency cap", "user_id", user.ID, "sent", sent)
+			return &SkipError{Reason: SkipFrequencyCap}
+		}
+	}
+
+	// 1. Fetch user's reservation history
+	reservations, err := run.userReservations(rg.nakupClient, user.ID)
+	if err != nil {
+		slog.Error("Failed to fetch user reservations", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to fetch reservations: %w", err)
+	}
+
+	slog.Info("Fetched reservations", "user_id", user.ID, "count", len(reservations))
+
+	// 2. Extract unique movie IDs and fetch movie details, remembering what
+	// was already watched and what is booked for an upcoming screening
+	movieMap := make(map[uuid.UUID]*spored.Movie)
+	history := newViewingHistory()
+	var userHistory []MovieHistory
+
+	for _, reservation := range reservations {
+		// Fetch timeslot to get movie ID
+		timeSlot, err := run.Spored.GetTimeSlot(reservation.TimeSlotID)
+		if err != nil {
+			slog.Warn("Failed to fetch timeslot", "timeslot_id", reservation.TimeSlotID, "error", err)
+			continue
+		}
+
+		history.add(timeSlot.MovieID, timeSlot.StartTime, now)
+
+		// Skip if we already have this movie
+		if _, exists := movieMap[timeSlot.MovieID]; exists {
+			continue
+		}
+
+		movieMap[timeSlot.MovieID] = &timeSlot.Movie
+		userHistory = append(userHistory, MovieHistory{
+			ID:          timeSlot.MovieID.String(),
+			Title:       timeSlot.Movie.Title,
+			Description: timeSlot.Movie.Description,
+			Rating:      timeSlot.Movie.Rating,
+		})
+	}
+
+	slog.Info("Extracted user history", "user_id", user.ID, "unique_movies", len(userHistory))
+	draft.History = userHistory
+
+	if rule := rg.audience.excludeActivity(history.pastReservations); rule != "" {
+		slog.Info("User excluded by audience rules", "user_id", user.ID, "rule", rule)
+		return &SkipError{Reason: rule}
+	}
+
+	// 3. Take upcoming movies from the run's catalog that the user has not
+	// seen or booked yet
+	upcomingMovies, excludedMovies := rg.rewatch.eligibleMovies(run.Catalog.Movies, history, now)
+
+	// ...and that were not recommended to them recently
+	recentMovieIDs, err := rg.store.GetRecommendedMovieIDsSince(user.ID, now.Add(-rg.delivery.cooldown))
+	if err != nil {
+		return fmt.Errorf("failed to fetch recent recommendations: %w", err)
+	}
+	recentlyRecommended := make(map[uuid.UUID]bool)
+	for _, movieID := range recentMovieIDs {
+		recentlyRecommended[movieID] = true
+	}
+	upcomingMovies, cooledDown := excludeMovies(upcomingMovies, recentlyRecommended)
+	excludedMovies = append(excludedMovies, cooledDown...)
+
+	// ...and that they did not turn down before
+	feedback, err := rg.loadFeedback(run, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feedback: %w", err)
+	}
+	upcomingMovies, rejected := excludeMovies(upcomingMovies, feedback.excluded)
+	excludedMovies = append(excludedMovies, rejected...)
+
+	// ...and that match their preferences
+	upcomingMovies, unwanted := preferredMovies(upcomingMovies, preferences, run.Catalog, now)
+	excludedMovies = append(excludedMovies, unwanted...)
+
+	draft.Candidates = upcomingMovies
+	draft.ExcludedMovies = excludedMovies
+	draft.LikedMovies = feedback.liked
+	draft.DislikedMovies = feedback.disliked
+
+	if len(upcomingMovies) == 0 {
+		slog.Info("No eligible upcoming movies", "user_id", user.ID, "excluded", len(excludedMovies))
+		return &SkipError{Reason: SkipNoEligibleMovies}
+	}
+
+	slog.Info("Filtered upcoming movies", "user_id", user.ID, "eligible", len(upcomingMovies), "excluded", len(excludedMovies))
+
+	// 4. Generate recommendation using the configured recommender
+	aiReq := RecommendationRequest{
+		UserHistory:    userHistory,
+		UpcomingMovies: upcomingMovies,
+		LikedMovies:    feedback.liked,
+		DislikedMovies: feedback.disliked,
+	}
+
+	result, err := rg.recommender.Recommend(ctx, aiReq)
+	if result != nil {
+		draft.Prompt = result.Trace.Prompt
+		draft.RawOutput = result.Trace.RawOutput
+		draft.Tier = result.Tier
+	}
+	if err != nil {
+		slog.Error("Failed to generate recommendation", "user_id", user.ID, "error", err)
+		return fmt.Errorf("failed to generate recommendation: %w", err)
+	}
+
+	aiResp := result.Best()
+	if aiResp == nil {
+		return fmt.Errorf("recommender returned no recommendation")
+	}
+
+	slog.Info("Recommendation generated",
+		"user_id", user.ID,
+		"movie_id", aiResp.MovieID,
+		"confidence", aiResp.ConfidenceScore,
+		"tier", result.Tier)
+	draft.Response = aiResp
+
+	// 5. Parse movie ID
+	movieID, err := uuid.Parse(aiResp.MovieID)
+	if err != nil {
+		slog.Error("Failed to parse movie ID", "movie_id", aiResp.MovieID, "error", err)
+		return fmt.Errorf("failed to parse movie ID: %w", err)
+	}
+
+	// Get full movie details
+	recommendedMovie, err := run.Spored.GetMovie(movieID)
+	if err != nil {
+		slog.Error("Failed to fetch recommended movie", "movie_id", movieID, "error", err)
+		return fmt.Errorf("failed to fetch recommended movie: %w", err)
+	}
+
+	draft.Movie = recommendedMovie
+
+	var timeSlotID uuid.UUID
+	if timeSlot := run.Catalog.NextTimeSlotOn(movieID, now, preferences.PreferredWeekdays()); timeSlot != nil {
+		timeSlotID = timeSlot.ID
+	}
+
+	recommendationID := draft.RecommendationID
+	draft.ReservationURL = rg.links.BuildReservationLink(recommendationID, movieID, timeSlotID)
+	draft.EmailSubject = fmt.Sprintf("Perfect Movie for You: %s", recommendedMovie.Title)
+	draft.EmailData = map[string]interface{}{
+		"UserName":             user.FirstName,
+		"MovieTitle":           recommendedMovie.Title,
+		"MovieDescription":     recommendedMovie.Description,
+		"MovieRating":          fmt.Sprintf("%.1f/10", recommendedMovie.Rating),
+		"RecommendationReason": aiResp.Reason,
+		"ReservationURL":       rg.links.ClickURL(recommendationID),
+		"ImageURL":             recommendedMovie.ImageURL,
+		"TrackingPixelURL":     rg.links.OpenPixelURL(recommendationID),
+		"LikeURL":              rg.links.FeedbackURL(recommendationID, models.FeedbackLike),
+		"DislikeURL":           rg.links.FeedbackURL(recommendationID, models.FeedbackDislike),
+		"NotInterestedURL":     rg.links.FeedbackURL(recommendationID, models.FeedbackNotInterested),
+		"UnsubscribeURL":       rg.links.UnsubscribeURL(user.ID),
+	}
+
+	return nil
+}
+
+// PreviewForUser runs generation for a single user without storing or
+// emailing anything. The draft is returned even if generation stopped early,
+// together with the error or SkipError that stopped it.
+func (rg *RecommendationGenerator) PreviewForUser(ctx context.Context, userID uuid.UUID) (*RecommendationDraft, error) {
+	user, err := rg.authClient.GetUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	run, err := rg.NewGenerationRun()
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &RecommendationDraft{}
+	err = rg.draftRecommendation(ctx, run, user, draft)
+	return draft, err
+}
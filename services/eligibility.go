@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RewatchPolicy decides whether a movie the user has already watched can be
+// recommended again. Movies the user holds an upcoming reservation for are
+// never recommended.
+type RewatchPolicy string
+
+const (
+	RewatchNever  RewatchPolicy = "never"
+	RewatchAfter  RewatchPolicy = "after"
+	RewatchAlways RewatchPolicy = "always"
+)
+
+type rewatchRule struct {
+	policy RewatchPolicy
+	after  time.Duration
+}
+
+func newRewatchRuleFromEnv() (rewatchRule, error) {
+	rule := rewatchRule{
+		policy: RewatchNever,
+		after:  180 * 24 * time.Hour,
+	}
+
+	if p := os.Getenv("RECOMMENDATION_REWATCH_POLICY"); p != "" {
+		rule.policy = RewatchPolicy(p)
+	}
+
+	switch rule.policy {
+	case RewatchNever, RewatchAfter, RewatchAlways:
+	default:
+		return rule, fmt.Errorf("invalid RECOMMENDATION_REWATCH_POLICY %q", rule.policy)
+	}
+
+	if d := os.Getenv("RECOMMENDATION_REWATCH_AFTER_DAYS"); d != "" {
+		days, err := strconv.Atoi(d)
+		if err != nil || days < 0 {
+			return rule, fmt.Errorf("invalid RECOMMENDATION_REWATCH_AFTER_DAYS %q", d)
+		}
+		rule.after = time.Duration(days) * 24 * time.Hour
+	}
+
+	return rule, nil
+}
+
+// allows reports whether a movie last watched at lastWatched may be
+// recommended again at now.
+func (r rewatchRule) allows(lastWatched, now time.Time) bool {
+	switch r.policy {
+	case RewatchAlways:
+		return true
+	case RewatchAfter:
+		return now.Sub(lastWatched) >= r.after
+	default:
+		return false
+	}
+}
+
+// viewingHistory records which movies a user has watched and which they have
+// booked for an upcoming screening.
+type viewingHistory struct {
+	lastWatched map[uuid.UUID]time.Time
+	booked      map[uuid.UUID]bool
+	// Reservations for screenings that already started, including repeats
+	pastReservations int
+}
+
+func newViewingHistory() *viewingHistory {
+	return &viewingHistory{
+		lastWatched: make(map[uuid.UUID]time.Time),
+		booked:      make(map[uuid.UUID]bool),
+	}
+}
+
+func (h *viewingHistory) add(movieID uuid.UUID, startTime, now time.Time) {
+	if startTime.After(now) {
+		h.booked[movieID] = true
+		return
+	}
+	h.pastReservations++
+	if startTime.After(h.lastWatched[movieID]) {
+		h.lastWatched[movieID] = startTime
+	}
+}
+
+// eligibleMovies filters movies down to the ones the user may be recommended
+// and returns the IDs of the excluded ones.
+func (r rewatchRule) eligibleMovies(movies []UpcomingMovie, history *viewingHistory, now time.Time) ([]UpcomingMovie, []string) {
+	eligible := []UpcomingMovie{}
+	excluded := []string{}
+
+	for _, movie := range movies {
+		movieID, err := uuid.Parse(movie.ID)
+		if err != nil {
+			eligible = append(eligible, movie)
+			continue
+		}
+
+		if history.booked[movieID] {
+			excluded = append(excluded, movie.ID)
+			continue
+		}
+
+		if lastWatched, watched := history.lastWatched[movieID]; watched && !r.allows(lastWatched, now) {
+			excluded = append(excluded, movie.ID)
+			continue
+		}
+
+		eligible = append(eligible, movie)
+	}
+
+	return eligible, excluded
+}
+
+// excludeMovies drops the movies in exclude and returns the IDs of the dropped
+// ones.
+func excludeMovies(movies []UpcomingMovie, exclude map[uuid.UUID]bool) ([]UpcomingMovie, []string) {
+	kept := []UpcomingMovie{}
+	excluded := []string{}
+
+	for _, movie := range movies {
+		movieID, err := uuid.Parse(movie.ID)
+		if err == nil && exclude[movieID] {
+			excluded = append(excluded, movie.ID)
+			continue
+		}
+		kept = append(kept, movie)
+	}
+
+	return kept, excluded
+}
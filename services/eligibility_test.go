@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEligibleMovies(t *testing.T) {
+	now := time.Now()
+	watchedLongAgo := uuid.New()
+	watchedRecently := uuid.New()
+	booked := uuid.New()
+	unseen := uuid.New()
+
+	history := newViewingHistory()
+	history.add(watchedLongAgo, now.AddDate(-1, 0, 0), now)
+	history.add(watchedRecently, now.AddDate(0, 0, -3), now)
+	history.add(booked, now.AddDate(0, 0, 2), now)
+	history.add(watchedRecently, now.AddDate(0, 0, -10), now)
+
+	// The upcoming booking does not count as a past reservation, a repeat
+	// visit does
+	assert.Equal(t, 3, history.pastReservations)
+
+	movies := []UpcomingMovie{
+		{ID: watchedLongAgo.String()},
+		{ID: watchedRecently.String()},
+		{ID: booked.String()},
+		{ID: unseen.String()},
+	}
+
+	tests := []struct {
+		name     string
+		rule     rewatchRule
+		expected []uuid.UUID
+	}{
+		{"Never", rewatchRule{policy: RewatchNever}, []uuid.UUID{unseen}},
+		{"After", rewatchRule{policy: RewatchAfter, after: 30 * 24 * time.Hour}, []uuid.UUID{watchedLongAgo, unseen}},
+		{"Always", rewatchRule{policy: RewatchAlways}, []uuid.UUID{watchedLongAgo, watchedRecently, unseen}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eligible, excluded := tt.rule.eligibleMovies(movies, history, now)
+
+			var ids []uuid.UUID
+			for _, movie := range eligible {
+				ids = append(ids, uuid.MustParse(movie.ID))
+			}
+
+			assert.Equal(t, tt.expected, ids)
+			assert.Len(t, excluded, len(movies)-len(tt.expected))
+		})
+	}
+}
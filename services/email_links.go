@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+)
+
+// EmailLinks builds the signed public URLs embedded in recommendation emails
+// and verifies the tokens they carry.
+type EmailLinks struct {
+	baseURL      string
+	campaign     string
+	reservations *ReservationLinkBuilder
+	signer       *TokenSigner
+}
+
+func NewEmailLinksFromEnv() (*EmailLinks, error) {
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		return nil, fmt.Errorf("PUBLIC_BASE_URL environment variable is required")
+	}
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid PUBLIC_BASE_URL: %w", err)
+	}
+
+	reservationURL := os.Getenv("RECOMMENDATION_RESERVATION_URL")
+	if reservationURL == "" {
+		reservationURL = "https://cinema.example.com/reserve?movie=" + PlaceholderMovieID
+	}
+	reservations, err := NewReservationLinkBuilder(reservationURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RECOMMENDATION_RESERVATION_URL: %w", err)
+	}
+
+	campaign := os.Getenv("RECOMMENDATION_CAMPAIGN")
+	if campaign == "" {
+		campaign = "recommendation"
+	}
+
+	signer, err := NewTokenSigner(os.Getenv("RECOMMENDATION_TOKEN_SECRET"))
+	if err != nil {
+		return nil, fmt.Errorf("RECOMMENDATION_TOKEN_SECRET environment variable is required")
+	}
+
+	return &EmailLinks{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		campaign:     campaign,
+		reservations: reservations,
+		signer:       signer,
+	}, nil
+}
+
+// OpenPixelURL points to a 1x1 image that marks the recommendation opened.
+func (l *EmailLinks) OpenPixelURL(recommendationID uuid.UUID) string {
+	return fmt.Sprintf("%s/api/v1/predlogi/o/%s", l.baseURL, l.signer.Sign(TokenOpen, recommendationID))
+}
+
+// ClickURL points to the redirect that records a click and forwards the user
+// to ReservationURL.
+func (l *EmailLinks) ClickURL(recommendationID uuid.UUID) string {
+	return fmt.Sprintf("%s/api/v1/predlogi/r/%s", l.baseURL, l.signer.Sign(TokenClick, recommendationID))
+}
+
+// UnsubscribeURL points to the unsubscribe link of a user. Opening it asks for
+// confirmation, a POST to it turns recommendation emails off right away.
+func (l *EmailLinks) UnsubscribeURL(userID uuid.UUID) string {
+	return fmt.Sprintf("%s/api/v1/predlogi/u/%s", l.baseURL, l.signer.Sign(TokenUnsubscribe, userID))
+}
+
+// FeedbackURL points to the link that records feedback of the given type on a
+// recommendation. Opening it asks for confirmation, the feedback is recorded
+// when the confirmation is posted.
+func (l *EmailLinks) FeedbackURL(recommendationID uuid.UUID, feedback models.FeedbackType) string {
+	return fmt.Sprintf("%s/api/v1/predlogi/f/%s/%s", l.baseURL, feedback, l.signer.Sign(FeedbackTokenPurpose(feedback), recommendationID))
+}
+
+// FeedbackTokenPurpose binds a feedback token to its feedback type, so a
+// "like" link cannot be turned into a "dislike" one.
+func FeedbackTokenPurpose(feedback models.FeedbackType) TokenPurpose {
+	return TokenPurpose("feedback:" + string(feedback))
+}
+
+// BuildReservationLink returns the deep link into the cinema frontend for a
+// recommendation. It is stored with the recommendation when it is created.
+func (l *EmailLinks) BuildReservationLink(recommendationID, movieID, timeSlotID uuid.UUID) string {
+	return l.reservations.Build(ReservationLink{
+		MovieID:          movieID,
+		TimeSlotID:       timeSlotID,
+		RecommendationID: recommendationID,
+		Campaign:         l.campaign,
+	})
+}
+
+// ReservationURL is where a click on a recommendation ends up, tagged with
+// UTM parameters for campaign analytics.
+func (l *EmailLinks) ReservationURL(recommendation models.Recommendation) string {
+	link := recommendation.ReservationURL
+	if link == "" {
+		link = l.BuildReservationLink(recommendation.ID, recommendation.MovieID, uuid.Nil)
+	}
+
+	target, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	query := target.Query()
+	query.Set("utm_source", "predlogi")
+	query.Set("utm_medium", "email")
+	query.Set("utm_campaign", l.campaign)
+	query.Set("utm_content", recommendation.ID.String())
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+func (l *EmailLinks) Verify(purpose TokenPurpose, token string) (uuid.UUID, error) {
+	return l.signer.Verify(purpose, token)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Tiers of the fallback chain, recorded with every recommendation.
+const (
+	TierPrimary   = "primary"
+	TierSecondary = "secondary"
+	TierLocal     = "local"
+)
+
+type recommenderTier struct {
+	name        string
+	recommender Recommender
+}
+
+// FallbackRecommender asks its tiers in order and returns the first
+// recommendation it gets, so a failing model does not cost the user their
+// email.
+type FallbackRecommender struct {
+	tiers []recommenderTier
+}
+
+func NewFallbackRecommender(primary Recommender) *FallbackRecommender {
+	return &FallbackRecommender{tiers: []recommenderTier{{name: TierPrimary, recommender: primary}}}
+}
+
+// Add appends a tier to the end of the chain.
+func (r *FallbackRecommender) Add(name string, recommender Recommender) {
+	r.tiers = append(r.tiers, recommenderTier{name: name, recommender: recommender})
+}
+
+// Recommend implements Recommender. The result records the tier that
+// produced it. If every tier fails, the primary tier's result is returned
+// so its trace can be inspected.
+func (r *FallbackRecommender) Recommend(ctx context.Context, req RecommendationRequest) (*RecommendationResult, error) {
+	var first *RecommendationResult
+	var errs []error
+
+	for _, tier := range r.tiers {
+		result, err := tier.recommender.Recommend(ctx, req)
+		if err == nil && result.Best() == nil {
+			err = errors.New("no recommendation returned")
+		}
+		if err == nil {
+			result.Tier = tier.name
+			return result, nil
+		}
+
+		if first == nil {
+			first = result
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", tier.name, err))
+
+		// Later tiers would fail the same way once the job is stopping
+		if ctx.Err() != nil {
+			break
+		}
+		slog.Warn("Recommender tier failed", "tier", tier.name, "error", err)
+	}
+
+	return first, errors.Join(errs...)
+}
+
+// HasObservers reports whether any tier learns from booking histories. The
+// generator only loads them for the recommender if one does.
+func (r *FallbackRecommender) HasObservers() bool {
+	for _, tier := range r.tiers {
+		if _, ok := tier.recommender.(HistoryObserver); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ObserveHistory implements HistoryObserver for every tier that learns from
+// booking histories.
+func (r *FallbackRecommender) ObserveHistory(ctx context.Context, userID uuid.UUID, movieIDs []uuid.UUID) error {
+	var errs []error
+	for _, tier := range r.tiers {
+		if observer, ok := tier.recommender.(HistoryObserver); ok {
+			if err := observer.ObserveHistory(ctx, userID, movieIDs); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRecommender struct {
+	result *RecommendationResult
+	err    error
+	calls  int
+	req    RecommendationRequest
+}
+
+func (r *stubRecommender) Recommend(ctx context.Context, req RecommendationRequest) (*RecommendationResult, error) {
+	r.calls++
+	r.req = req
+	return r.result, r.err
+}
+
+func TestFallbackRecommender(t *testing.T) {
+	primary := &stubRecommender{
+		result: &RecommendationResult{Trace: RecommendationTrace{RawOutput: "not json"}},
+		err:    errors.New("bad output"),
+	}
+	secondary := &stubRecommender{result: &RecommendationResult{}}
+	local := &stubRecommender{result: &RecommendationResult{Recommendations: []RecommendationResponse{{MovieID: "local"}}}}
+
+	chain := NewFallbackRecommender(primary)
+	chain.Add(TierSecondary, secondary)
+	chain.Add(TierLocal, local)
+
+	result, err := chain.Recommend(context.Background(), RecommendationRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, TierLocal, result.Tier)
+	assert.Equal(t, "local", result.Best().MovieID)
+	assert.Equal(t, 1, secondary.calls)
+}
+
+func TestFallbackRecommenderAllTiersFail(t *testing.T) {
+	primary := &stubRecommender{
+		result: &RecommendationResult{Trace: RecommendationTrace{RawOutput: "not json"}},
+		err:    errors.New("bad output"),
+	}
+	local := &stubRecommender{err: errors.New("no history")}
+
+	chain := NewFallbackRecommender(primary)
+	chain.Add(TierLocal, local)
+
+	result, err := chain.Recommend(context.Background(), RecommendationRequest{})
+	assert.ErrorContains(t, err, "bad output")
+	assert.ErrorContains(t, err, "no history")
+	assert.Equal(t, "not json", result.Trace.RawOutput)
+}
+
+func TestFallbackRecommenderHasObservers(t *testing.T) {
+	fallback := NewFallbackRecommender(&stubRecommender{})
+	assert.False(t, fallback.HasObservers())
+
+	fallback.Add(TierLocal, NewCollaborativeRecommender(nil))
+	assert.True(t, fallback.HasObservers())
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"log/slog"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+)
+
+// userFeedback is what a user told us about earlier recommendations.
+type userFeedback struct {
+	// excluded holds movies the user gave negative feedback on
+	excluded map[uuid.UUID]bool
+	liked    []string
+	disliked []string
+}
+
+func (rg *RecommendationGenerator) loadFeedback(run *GenerationRun, userID uuid.UUID) (userFeedback, error) {
+	feedback := userFeedback{excluded: make(map[uuid.UUID]bool)}
+
+	entries, err := rg.store.GetFeedback(userID)
+	if err != nil {
+		return feedback, err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, entry := range entries {
+		// Entries are newest first, so the latest verdict on a movie wins
+		if seen[entry.MovieID] {
+			continue
+		}
+		seen[entry.MovieID] = true
+
+		if entry.Type.Negative() {
+			feedback.excluded[entry.MovieID] = true
+		}
+		if entry.Type != models.FeedbackLike && entry.Type != models.FeedbackDislike {
+			continue
+		}
+
+		movie, err := run.Spored.GetMovie(entry.MovieID)
+		if err != nil {
+			slog.Warn("Failed to fetch movie for feedback", "movie_id", entry.MovieID, "error", err)
+			continue
+		}
+
+		if entry.Type == models.FeedbackLike {
+			feedback.liked = append(feedback.liked, movie.Title)
+		} else {
+			feedback.disliked = append(feedback.disliked, movie.Title)
+		}
+	}
+
+	return feedback, nil
+}
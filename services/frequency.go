@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// deliveryPolicy limits how often users are emailed and how soon the same
+// movie may be recommended to them again.
+type deliveryPolicy struct {
+	// cooldown is how long a recommended movie is not recommended again
+	cooldown time.Duration
+	// maxEmails is how many recommendations a user gets within window, zero
+	// means no limit
+	maxEmails int
+	window    time.Duration
+}
+
+func newDeliveryPolicyFromEnv() (deliveryPolicy, error) {
+	var policy deliveryPolicy
+
+	days, err := getNonNegativeIntEnv("RECOMMENDATION_COOLDOWN_DAYS", 30)
+	if err != nil {
+		return policy, err
+	}
+	policy.cooldown = time.Duration(days) * 24 * time.Hour
+
+	policy.maxEmails, err = getNonNegativeIntEnv("RECOMMENDATION_MAX_EMAILS", 2)
+	if err != nil {
+		return policy, err
+	}
+
+	days, err = getNonNegativeIntEnv("RECOMMENDATION_FREQUENCY_WINDOW_DAYS", 7)
+	if err != nil {
+		return policy, err
+	}
+	policy.window = time.Duration(days) * 24 * time.Hour
+
+	return policy, nil
+}
+
+func getNonNegativeIntEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return parsed, nil
+}
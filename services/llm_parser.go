@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseRecommendation reads a recommendation out of a model reply. Models
+// often wrap the JSON in a markdown code fence or add prose around it, so
+// the first JSON object in the reply is used.
+func parseRecommendation(content string) (*RecommendationResponse, error) {
+	object, err := extractJSONObject(stripCodeFence(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		MovieID         string        `json:"movie_id"`
+		MovieTitle      string        `json:"movie_title"`
+		Reason          string        `json:"reason"`
+		ConfidenceScore flexibleFloat `json:"confidence_score"`
+	}
+	if err := json.Unmarshal([]byte(object), &parsed); err != nil {
+		return nil, err
+	}
+	if parsed.MovieID == "" {
+		return nil, errors.New("movie_id is missing")
+	}
+
+	return &RecommendationResponse{
+		MovieID:         parsed.MovieID,
+		MovieTitle:      parsed.MovieTitle,
+		Reason:          parsed.Reason,
+		ConfidenceScore: float64(parsed.ConfidenceScore),
+	}, nil
+}
+
+// stripCodeFence removes a surrounding markdown code fence, with or without a
+// language tag.
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+
+	// Drop the opening fence line, including a language tag like ```json
+	if newline := strings.Index(content, "\n"); newline >= 0 {
+		content = content[newline+1:]
+	} else {
+		content = strings.TrimPrefix(content, "```")
+	}
+	if end := strings.LastIndex(content, "```"); end >= 0 {
+		content = content[:end]
+	}
+	return strings.TrimSpace(content)
+}
+
+// extractJSONObject returns the first balanced JSON object in content,
+// ignoring braces inside strings.
+func extractJSONObject(content string) (string, error) {
+	start := strings.Index(content, "{")
+	if start < 0 {
+		return "", errors.New("no JSON object found")
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return content[start : i+1], nil
+			}
+		}
+	}
+
+	return "", errors.New("unterminated JSON object")
+}
+
+// flexibleFloat accepts a JSON number or a finite number in a string, like
+// "0.8".
+type flexibleFloat float64
+
+func (f *flexibleFloat) UnmarshalJSON(data []byte) error {
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*f = flexibleFloat(number)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("confidence_score must be a number, got %s", data)
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	// ParseFloat accepts NaN and infinities, which no clamp or JSON encoder
+	// handles
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return fmt.Errorf("confidence_score must be a number, got %q", text)
+	}
+	*f = flexibleFloat(number)
+	return nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecommendation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"plain", `{"movie_id": "abc", "movie_title": "Dune", "reason": "Epic", "confidence_score": 0.8}`},
+		{"code fence", "```json\n{\"movie_id\": \"abc\", \"movie_title\": \"Dune\", \"reason\": \"Epic\", \"confidence_score\": 0.8}\n```"},
+		{"surrounding prose", `Sure! Here it is: {"movie_id": "abc", "movie_title": "Dune", "reason": "Epic {really}", "confidence_score": 0.8} Enjoy.`},
+		{"string confidence", `{"movie_id": "abc", "movie_title": "Dune", "reason": "Epic", "confidence_score": "0.8"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommendation, err := parseRecommendation(tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, "abc", recommendation.MovieID)
+			assert.Equal(t, "Dune", recommendation.MovieTitle)
+			assert.Equal(t, 0.8, recommendation.ConfidenceScore)
+		})
+	}
+}
+
+func TestParseRecommendationErrors(t *testing.T) {
+	for _, content := range []string{
+		"I recommend Dune.",
+		`{"movie_id": "abc", "reason": "unterminated`,
+		`{"movie_title": "Dune"}`,
+		`{"movie_id": "abc", "confidence_score": "high"}`,
+		`{"movie_id": "abc", "confidence_score": "NaN"}`,
+		`{"movie_id": "abc", "confidence_score": "Inf"}`,
+		`{"movie_id": "abc", "confidence_score": "+Infinity"}`,
+	} {
+		_, err := parseRecommendation(content)
+		assert.Error(t, err, content)
+	}
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -11,8 +10,10 @@ import (
 )
 
 type MovieHistory struct {
-	Title  string  `json:"title"`
-	Rating float64 `json:"rating"`
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Rating      float64 `json:"rating"`
 }
 
 type UpcomingMovie struct {
@@ -25,6 +26,8 @@ type UpcomingMovie struct {
 type RecommendationRequest struct {
 	UserHistory    []MovieHistory  `json:"user_history"`
 	UpcomingMovies []UpcomingMovie `json:"upcoming_movies"`
+	LikedMovies    []string        `json:"liked_movies,omitempty"`
+	DislikedMovies []string        `json:"disliked_movies,omitempty"`
 }
 
 type RecommendationResponse struct {
@@ -41,16 +44,22 @@ type OpenAIService struct {
 }
 
 func NewOpenAIService() (*OpenAIService, error) {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("OPENROUTER_API_KEY environment variable is required")
-	}
-
 	model := os.Getenv("OPENROUTER_MODEL")
 	if model == "" {
 		return nil, fmt.Errorf("OPENROUTER_MODEL environment variable is required")
 	}
 
+	return NewOpenAIServiceWithModel(model)
+}
+
+// NewOpenAIServiceWithModel is like NewOpenAIService but uses the given model
+// instead of OPENROUTER_MODEL.
+func NewOpenAIServiceWithModel(model string) (*OpenAIService, error) {
+	apiKey := os.Getenv("OPENROUTER_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("OPENROUTER_API_KEY environment variable is required")
+	}
+
 	maxTokens := 500
 	if mt := os.Getenv("OPENROUTER_MAX_TOKENS"); mt != "" {
 		fmt.Sscanf(mt, "%d", &maxTokens)
@@ -74,50 +83,72 @@ func NewOpenAIService() (*OpenAIService, error) {
 	}, nil
 }
 
-func (s *OpenAIService) GenerateRecommendation(ctx context.Context, req RecommendationRequest) (*RecommendationResponse, error) {
+// Recommend implements Recommender. The model picks a single movie, so the
+// result holds at most one recommendation.
+func (s *OpenAIService) Recommend(ctx context.Context, req RecommendationRequest) (*RecommendationResult, error) {
+	recommendation, trace, err := s.generate(ctx, req)
+	result := &RecommendationResult{Trace: trace}
+	if err != nil {
+		return result, err
+	}
+
+	result.Recommendations = []RecommendationResponse{*recommendation}
+	return result, nil
+}
+
+// generate asks the model for a recommendation and returns it together with
+// the prompt and the raw model output, as far as it got.
+func (s *OpenAIService) generate(ctx context.Context, req RecommendationRequest) (*RecommendationResponse, RecommendationTrace, error) {
+	var trace RecommendationTrace
 	if len(req.UpcomingMovies) == 0 {
-		return nil, fmt.Errorf("no upcoming movies available")
+		return nil, trace, fmt.Errorf("no upcoming movies available")
 	}
 
 	prompt := s.buildPrompt(req)
+	trace.Prompt = prompt
 
 	slog.Info("Generating recommendation with OpenAI", "model", s.model)
 
-	resp, err := s.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: s.model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    "system",
-					Content: "You are a movie recommendation assistant for a cinema. Based on user's viewing history and upcoming movies, recommend ONE movie that would best suit this user. Respond ONLY with valid JSON in this exact format: {\"movie_id\": \"<id>\", \"movie_title\": \"<title>\", \"reason\": \"<personalized explanation>\", \"confidence_score\": <0.0-1.0>}. Do not include any other text.",
-				},
-				{
-					Role:    "user",
-					Content: prompt,
-				},
-			},
-			MaxTokens:   s.maxTokens,
-			Temperature: 0.7,
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    "system",
+			Content: "You are a movie recommendation assistant for a cinema. Based on user's viewing history and upcoming movies, recommend ONE movie that would best suit this user. Respond ONLY with valid JSON in this exact format: {\"movie_id\": \"<id>\", \"movie_title\": \"<title>\", \"reason\": \"<personalized explanation>\", \"confidence_score\": <0.0-1.0>}. Do not include any other text.",
+		},
+		{
+			Role:    "user",
+			Content: prompt,
 		},
-	)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate recommendation: %w", err)
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no recommendation generated")
+	content, err := s.complete(ctx, messages)
+	if err != nil {
+		return nil, trace, err
 	}
+	trace.RawOutput = content
 
-	content := resp.Choices[0].Message.Content
+	recommendation, err := parseRecommendation(content)
+	if err != nil {
+		// Tell the model what was wrong and give it one more chance
+		slog.Warn("Failed to parse OpenAI response, retrying", "content", content, "error", err)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: "assistant", Content: content},
+			openai.ChatCompletionMessage{
+				Role:    "user",
+				Content: fmt.Sprintf("Your response could not be parsed: %s. Respond ONLY with the JSON object.", err),
+			},
+		)
 
-	slog.Info("OpenAI response received", "content", content)
+		content, err = s.complete(ctx, messages)
+		if err != nil {
+			return nil, trace, err
+		}
+		trace.RawOutput += "\n\n--- retry ---\n\n" + content
 
-	var recommendation RecommendationResponse
-	if err := json.Unmarshal([]byte(content), &recommendation); err != nil {
-		slog.Error("Failed to parse OpenAI response", "content", content, "error", err)
-		return nil, fmt.Errorf("failed to parse recommendation response: %w", err)
+		recommendation, err = parseRecommendation(content)
+		if err != nil {
+			slog.Error("Failed to parse OpenAI response", "content", content, "error", err)
+			return nil, trace, fmt.Errorf("failed to parse recommendation response: %w", err)
+		}
 	}
 
 	// Validate that the recommended movie is in the upcoming list
@@ -145,7 +176,34 @@ func (s *OpenAIService) GenerateRecommendation(ctx context.Context, req Recommen
 		recommendation.ConfidenceScore = 1
 	}
 
-	return &recommendation, nil
+	return recommendation, trace, nil
+}
+
+// complete sends the conversation to the model and returns its reply.
+func (s *OpenAIService) complete(ctx context.Context, messages []openai.ChatCompletionMessage) (string, error) {
+	resp, err := s.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model:       s.model,
+			Messages:    messages,
+			MaxTokens:   s.maxTokens,
+			Temperature: 0.7,
+		},
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to generate recommendation: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no recommendation generated")
+	}
+
+	content := resp.Choices[0].Message.Content
+
+	slog.Info("OpenAI response received", "content", content)
+
+	return content, nil
 }
 
 func (s *OpenAIService) buildPrompt(req RecommendationRequest) string {
@@ -159,6 +217,20 @@ func (s *OpenAIService) buildPrompt(req RecommendationRequest) string {
 		}
 	}
 
+	if len(req.LikedMovies) > 0 {
+		prompt += "\nRecommendations the user liked:\n"
+		for _, title := range req.LikedMovies {
+			prompt += fmt.Sprintf("- %s\n", title)
+		}
+	}
+
+	if len(req.DislikedMovies) > 0 {
+		prompt += "\nRecommendations the user disliked:\n"
+		for _, title := range req.DislikedMovies {
+			prompt += fmt.Sprintf("- %s\n", title)
+		}
+	}
+
 	prompt += "\nUpcoming movies:\n"
 	for _, movie := range req.UpcomingMovies {
 		prompt += fmt.Sprintf("- ID: %s, Title: %s, Description: %s, Rating: %.1f/10\n",
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+)
+
+// preferredMovies drops the movies a user excluded in their preferences,
+// either directly or by keyword, and the ones not screening on any of their
+// preferred days. It returns the IDs of the dropped movies.
+func preferredMovies(movies []UpcomingMovie, preferences models.RecommendationPreferences, catalog *Catalog, now time.Time) ([]UpcomingMovie, []string) {
+	excludedIDs := make(map[uuid.UUID]bool)
+	for _, movieID := range preferences.ExcludedMovieIDs {
+		excludedIDs[movieID] = true
+	}
+
+	keywords := []string{}
+	for _, keyword := range preferences.ExcludedKeywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	days := preferences.PreferredWeekdays()
+
+	kept := []UpcomingMovie{}
+	excluded := []string{}
+
+	for _, movie := range movies {
+		movieID, err := uuid.Parse(movie.ID)
+		if err != nil {
+			kept = append(kept, movie)
+			continue
+		}
+
+		if excludedIDs[movieID] || matchesKeyword(movie, keywords) {
+			excluded = append(excluded, movie.ID)
+			continue
+		}
+
+		if len(days) > 0 && catalog.NextTimeSlotOn(movieID, now, days) == nil {
+			excluded = append(excluded, movie.ID)
+			continue
+		}
+
+		kept = append(kept, movie)
+	}
+
+	return kept, excluded
+}
+
+func matchesKeyword(movie UpcomingMovie, keywords []string) bool {
+	text := strings.ToLower(movie.Title + " " + movie.Description)
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
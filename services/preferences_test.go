@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredMovies(t *testing.T) {
+	// A Wednesday, so the next Friday and Saturday are within the week
+	now := time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC)
+	friday := now.AddDate(0, 0, 2)
+	saturday := now.AddDate(0, 0, 3)
+
+	horror := uuid.New()
+	excluded := uuid.New()
+	onFriday := uuid.New()
+	onSaturday := uuid.New()
+
+	catalog := &Catalog{
+		TimeSlots: []spored.TimeSlot{
+			{ID: uuid.New(), MovieID: horror, StartTime: friday},
+			{ID: uuid.New(), MovieID: excluded, StartTime: friday},
+			{ID: uuid.New(), MovieID: onFriday, StartTime: friday},
+			{ID: uuid.New(), MovieID: onSaturday, StartTime: saturday},
+		},
+	}
+
+	movies := []UpcomingMovie{
+		{ID: horror.String(), Title: "Night Terrors", Description: "A HORROR classic"},
+		{ID: excluded.String(), Title: "Sequel"},
+		{ID: onFriday.String(), Title: "Comedy"},
+		{ID: onSaturday.String(), Title: "Drama"},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*models.RecommendationPreferences)
+		expected []uuid.UUID
+	}{
+		{"Defaults", func(p *models.RecommendationPreferences) {}, []uuid.UUID{horror, excluded, onFriday, onSaturday}},
+		{"ExcludedMovie", func(p *models.RecommendationPreferences) {
+			p.ExcludedMovieIDs = []uuid.UUID{excluded}
+		}, []uuid.UUID{horror, onFriday, onSaturday}},
+		{"ExcludedKeyword", func(p *models.RecommendationPreferences) {
+			p.ExcludedKeywords = []string{" horror "}
+		}, []uuid.UUID{excluded, onFriday, onSaturday}},
+		{"PreferredDays", func(p *models.RecommendationPreferences) {
+			p.PreferredDays = []string{"saturday"}
+		}, []uuid.UUID{onSaturday}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferences := models.DefaultRecommendationPreferences(uuid.New())
+			tt.modify(&preferences)
+
+			kept, dropped := preferredMovies(movies, preferences, catalog, now)
+
+			var ids []uuid.UUID
+			for _, movie := range kept {
+				ids = append(ids, uuid.MustParse(movie.ID))
+			}
+
+			assert.Equal(t, tt.expected, ids)
+			assert.Len(t, dropped, len(movies)-len(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import "github.com/google/uuid"
+
+type ProgressEventType string
+
+const (
+	EventUserStarted      ProgressEventType = "user_started"
+	EventMovieRecommended ProgressEventType = "movie_recommended"
+	EventEmailPublished   ProgressEventType = "email_published"
+	EventEmailFailed      ProgressEventType = "email_failed"
+	EventUserFailed       ProgressEventType = "user_failed"
+	EventUserSkipped      ProgressEventType = "user_skipped"
+)
+
+// ProgressEvent describes a step of generating a recommendation for a single
+// user. Fields that do not apply to the event type are left empty.
+type ProgressEvent struct {
+	Type       ProgressEventType
+	UserID     uuid.UUID
+	UserIndex  int
+	TotalUsers int
+	MovieID    uuid.UUID
+	MovieTitle string
+	Error      string
+}
+
+// ProgressReporter receives progress events while recommendations are being
+// generated.
+type ProgressReporter interface {
+	Report(event ProgressEvent)
+}
+
+type noopProgressReporter struct{}
+
+func (noopProgressReporter) Report(ProgressEvent) {}
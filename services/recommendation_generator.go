@@ -3,42 +3,97 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
-	"time"
+	"sync"
 
 	"github.com/PRPO-skupina-02/common/messaging"
 	"github.com/PRPO-skupina-02/predlogi/clients/auth"
-	"github.com/PRPO-skupina-02/predlogi/clients/nakup"
 	"github.com/PRPO-skupina-02/predlogi/clients/spored"
 	"github.com/PRPO-skupina-02/predlogi/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// maxRecordedErrors caps how many per-user errors a summary keeps.
+const maxRecordedErrors = 100
+
+type UserError struct {
+	UserID uuid.UUID `json:"user_id"`
+	Error  string    `json:"error"`
+}
+
+// GenerationSummary describes the outcome of a GenerateForAllUsers run. It is
+// safe for concurrent use by the workers of a run.
+type GenerationSummary struct {
+	TotalUsers   int
+	SuccessCount int
+	FailureCount int
+	SkippedCount int
+	SkipReasons  map[string]int
+	Tiers        map[string]int // Successful users by the fallback tier that recommended their movie
+	Errors       []UserError
+	Catalog      *Catalog
+	SporedCache  spored.CacheStats
+
+	mu sync.Mutex
+}
+
+func (s *GenerationSummary) recordSuccess(tier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SuccessCount++
+	s.Tiers[tier]++
+}
+
+func (s *GenerationSummary) recordSkip(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.SkippedCount++
+	s.SkipReasons[reason]++
+}
+
+func (s *GenerationSummary) recordFailure(userID uuid.UUID, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FailureCount++
+	if len(s.Errors) < maxRecordedErrors {
+		s.Errors = append(s.Errors, UserError{UserID: userID, Error: err.Error()})
+	}
+}
+
 type RecommendationGenerator struct {
-	db            *gorm.DB
-	authClient    *auth.Client
-	nakupClient   *nakup.Client
-	sporedClient  *spored.Client
-	openaiService *OpenAIService
+	store         recommendationStore
+	authClient    UserSource
+	nakupClient   ReservationSource
+	sporedClient  ScheduleSource
+	recommender   Recommender
+	observer      HistoryObserver
 	publisher     *messaging.Publisher
+	links         *EmailLinks
+	progress      ProgressReporter
 	lookaheadDays int
+	workers       int
+	rewatch       rewatchRule
+	delivery      deliveryPolicy
+	audience      audienceRules
+	dryRun        bool
 }
 
 func NewRecommendationGenerator(
 	db *gorm.DB,
-	authClient *auth.Client,
-	nakupClient *nakup.Client,
-	sporedClient *spored.Client,
-	openaiService *OpenAIService,
+	authClient UserSource,
+	nakupClient ReservationSource,
+	sporedClient ScheduleSource,
+	recommender Recommender,
 	rabbitmqURL string,
 ) (*RecommendationGenerator, error) {
-	publisher, err := messaging.NewPublisher(rabbitmqURL)
+	links, err := NewEmailLinksFromEnv()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create publisher: %w", err)
+		return nil, err
 	}
 
 	lookaheadDays := 7
@@ -48,179 +103,178 @@ func NewRecommendationGenerator(
 		}
 	}
 
-	return &RecommendationGenerator{
-		db:            db,
-		authClient:    authClient,
-		nakupClient:   nakupClient,
-		sporedClient:  sporedClient,
-		openaiService: openaiService,
-		publisher:     publisher,
-		lookaheadDays: lookaheadDays,
-	}, nil
-}
-
-func (rg *RecommendationGenerator) Close() error {
-	if rg.publisher != nil {
-		return rg.publisher.Close()
+	workers := 4
+	if w := os.Getenv("RECOMMENDATION_WORKERS"); w != "" {
+		if parsed, err := strconv.Atoi(w); err == nil && parsed > 0 {
+			workers = parsed
+		}
 	}
-	return nil
-}
 
-func (rg *RecommendationGenerator) GenerateForUser(ctx context.Context, user *auth.User) error {
-	slog.Info("Generating recommendation for user", "user_id", user.ID, "email", user.Email)
-
-	// 1. Fetch user's reservation history
-	reservations, err := rg.nakupClient.GetUserReservations(user.ID)
+	rewatch, err := newRewatchRuleFromEnv()
 	if err != nil {
-		slog.Error("Failed to fetch user reservations", "user_id", user.ID, "error", err)
-		return fmt.Errorf("failed to fetch reservations: %w", err)
+		return nil, err
 	}
 
-	slog.Info("Fetched reservations", "user_id", user.ID, "count", len(reservations))
+	delivery, err := newDeliveryPolicyFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
-	// 2. Extract unique movie IDs and fetch movie details
-	movieMap := make(map[uuid.UUID]*spored.Movie)
-	var userHistory []MovieHistory
+	audience, err := newAudienceRulesFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, reservation := range reservations {
-		// Fetch timeslot to get movie ID
-		timeSlot, err := rg.sporedClient.GetTimeSlot(reservation.TimeSlotID)
+	// Without a RabbitMQ URL the generator can only preview and dry run
+	var publisher *messaging.Publisher
+	if rabbitmqURL != "" {
+		publisher, err = messaging.NewPublisher(rabbitmqURL)
 		if err != nil {
-			slog.Warn("Failed to fetch timeslot", "timeslot_id", reservation.TimeSlotID, "error", err)
-			continue
-		}
-
-		// Skip if we already have this movie
-		if _, exists := movieMap[timeSlot.MovieID]; exists {
-			continue
+			return nil, fmt.Errorf("failed to create publisher: %w", err)
 		}
-
-		movieMap[timeSlot.MovieID] = &timeSlot.Movie
-		userHistory = append(userHistory, MovieHistory{
-			Title:  timeSlot.Movie.Title,
-			Rating: timeSlot.Movie.Rating,
-		})
 	}
 
-	slog.Info("Extracted user history", "user_id", user.ID, "unique_movies", len(userHistory))
-
-	// 3. Fetch upcoming schedule
-	startDate := time.Now()
-	endDate := startDate.AddDate(0, 0, rg.lookaheadDays)
-
-	upcomingTimeSlots, err := rg.sporedClient.GetUpcomingTimeSlots(startDate, endDate)
-	if err != nil {
-		slog.Error("Failed to fetch upcoming schedule", "error", err)
-		return fmt.Errorf("failed to fetch upcoming schedule: %w", err)
+	// Recommenders that learn from booking histories get every user's history
+	// at the start of a run
+	observer, _ := recommender.(HistoryObserver)
+	if fallback, ok := recommender.(*FallbackRecommender); ok && !fallback.HasObservers() {
+		observer = nil
 	}
 
-	slog.Info("Fetched upcoming timeslots", "count", len(upcomingTimeSlots))
+	return &RecommendationGenerator{
+		store:         gormStore{db: db},
+		authClient:    authClient,
+		nakupClient:   nakupClient,
+		sporedClient:  sporedClient,
+		recommender:   recommender,
+		observer:      observer,
+		publisher:     publisher,
+		links:         links,
+		progress:      noopProgressReporter{},
+		lookaheadDays: lookaheadDays,
+		workers:       workers,
+		rewatch:       rewatch,
+		delivery:      delivery,
+		audience:      audience,
+	}, nil
+}
 
-	// 4. Extract unique upcoming movies
-	upcomingMoviesMap := make(map[uuid.UUID]*spored.Movie)
-	var upcomingMovies []UpcomingMovie
+// SetProgressReporter makes the generator report per-user progress to reporter.
+func (rg *RecommendationGenerator) SetProgressReporter(reporter ProgressReporter) {
+	rg.progress = reporter
+}
 
-	for _, timeSlot := range upcomingTimeSlots {
-		if _, exists := upcomingMoviesMap[timeSlot.MovieID]; !exists {
-			upcomingMoviesMap[timeSlot.MovieID] = &timeSlot.Movie
-			upcomingMovies = append(upcomingMovies, UpcomingMovie{
-				ID:          timeSlot.MovieID.String(),
-				Title:       timeSlot.Movie.Title,
-				Description: timeSlot.Movie.Description,
-				Rating:      timeSlot.Movie.Rating,
-			})
-		}
-	}
+// SetDryRun makes the generator store recommendations with StatusDryRun
+// instead of emailing them.
+func (rg *RecommendationGenerator) SetDryRun(dryRun bool) {
+	rg.dryRun = dryRun
+}
 
-	if len(upcomingMovies) == 0 {
-		slog.Warn("No upcoming movies available", "user_id", user.ID)
-		return fmt.Errorf("no upcoming movies available")
+func (rg *RecommendationGenerator) Close() error {
+	if rg.publisher != nil {
+		return rg.publisher.Close()
 	}
+	return nil
+}
 
-	slog.Info("Extracted upcoming movies", "count", len(upcomingMovies))
+func (rg *RecommendationGenerator) GenerateForUser(ctx context.Context, run *GenerationRun, user *auth.User) (*models.Recommendation, error) {
+	slog.Info("Generating recommendation for user", "user_id", user.ID, "email", user.Email)
 
-	// 5. Generate recommendation using OpenAI
-	aiReq := RecommendationRequest{
-		UserHistory:    userHistory,
-		UpcomingMovies: upcomingMovies,
+	if rg.publisher == nil && !rg.dryRun {
+		return nil, errors.New("no email publisher configured")
 	}
 
-	aiResp, err := rg.openaiService.GenerateRecommendation(ctx, aiReq)
-	if err != nil {
-		slog.Error("Failed to generate AI recommendation", "user_id", user.ID, "error", err)
-		return fmt.Errorf("failed to generate AI recommendation: %w", err)
+	// 0-5. Decide what to recommend and prepare the email
+	draft := &RecommendationDraft{}
+	if err := rg.draftRecommendation(ctx, run, user, draft); err != nil {
+		return nil, err
 	}
 
-	slog.Info("AI recommendation generated",
-		"user_id", user.ID,
-		"movie_id", aiResp.MovieID,
-		"confidence", aiResp.ConfidenceScore)
-
-	// 6. Parse movie ID
-	movieID, err := uuid.Parse(aiResp.MovieID)
-	if err != nil {
-		slog.Error("Failed to parse movie ID", "movie_id", aiResp.MovieID, "error", err)
-		return fmt.Errorf("failed to parse movie ID: %w", err)
+	movieID := draft.Movie.ID
+	recommendedMovie := draft.Movie
+	emailData := draft.EmailData
+
+	// 6. Store recommendation in database
+	generationContext := map[string]interface{}{
+		"user_history":    draft.History,
+		"liked_movies":    draft.LikedMovies,
+		"disliked_movies": draft.DislikedMovies,
+		"upcoming_movies": draft.Candidates,
+		"excluded_movies": draft.ExcludedMovies,
+		"ai_response":     draft.Response,
 	}
 
-	// Get full movie details
-	recommendedMovie, err := rg.sporedClient.GetMovie(movieID)
-	if err != nil {
-		slog.Error("Failed to fetch recommended movie", "movie_id", movieID, "error", err)
-		return fmt.Errorf("failed to fetch recommended movie: %w", err)
+	status := models.StatusPending
+	if rg.dryRun {
+		// Keep what would have been emailed so it can be reviewed. The
+		// unsubscribe link is signed for the user, not the recommendation,
+		// so it would work for real and is left out.
+		emailData["UnsubscribeURL"] = ""
+		generationContext["email_data"] = emailData
+		status = models.StatusDryRun
 	}
 
-	// 7. Store recommendation in database
-	contextJSON, _ := json.Marshal(map[string]interface{}{
-		"user_history":    userHistory,
-		"upcoming_movies": upcomingMovies,
-		"ai_response":     aiResp,
-	})
+	contextJSON, _ := json.Marshal(generationContext)
 
 	recommendation := models.Recommendation{
+		ID:                draft.RecommendationID,
 		UserID:            user.ID,
 		MovieID:           movieID,
-		Reason:            aiResp.Reason,
-		ConfidenceScore:   aiResp.ConfidenceScore,
-		Status:            models.StatusPending,
+		Reason:            draft.Response.Reason,
+		ConfidenceScore:   draft.Response.ConfidenceScore,
+		Status:            status,
+		Tier:              draft.Tier,
 		GenerationContext: string(contextJSON),
 		EmailTo:           user.Email,
-		EmailSubject:      fmt.Sprintf("Perfect Movie for You: %s", recommendedMovie.Title),
+		EmailSubject:      draft.EmailSubject,
+		ReservationURL:    draft.ReservationURL,
 	}
 
-	if err := recommendation.Create(rg.db); err != nil {
+	if err := rg.store.CreateRecommendation(&recommendation); err != nil {
 		slog.Error("Failed to save recommendation", "user_id", user.ID, "error", err)
-		return fmt.Errorf("failed to save recommendation: %w", err)
+		return nil, fmt.Errorf("failed to save recommendation: %w", err)
 	}
 
 	slog.Info("Recommendation saved", "recommendation_id", recommendation.ID)
 
-	// 8. Send email notification via RabbitMQ
-	reservationURL := fmt.Sprintf("https://cinema.example.com/reserve?movie=%s", movieID.String())
-
-	emailMsg := messaging.NewEmailMessage(
-		user.Email,
-		"recommendation",
-		map[string]interface{}{
-			"UserName":             user.FirstName,
-			"MovieTitle":           recommendedMovie.Title,
-			"MovieDescription":     recommendedMovie.Description,
-			"MovieRating":          fmt.Sprintf("%.1f/10", recommendedMovie.Rating),
-			"RecommendationReason": aiResp.Reason,
-			"ReservationURL":       reservationURL,
-			"ImageURL":             recommendedMovie.ImageURL,
-		},
-	)
+	rg.progress.Report(ProgressEvent{
+		Type:       EventMovieRecommended,
+		UserID:     user.ID,
+		MovieID:    movieID,
+		MovieTitle: recommendedMovie.Title,
+	})
+
+	if rg.dryRun {
+		slog.Info("Dry run, recommendation not emailed", "user_id", user.ID, "recommendation_id", recommendation.ID)
+		return &recommendation, nil
+	}
+
+	// 7. Send email notification via RabbitMQ
+	emailMsg := messaging.NewEmailMessage(user.Email, "recommendation", emailData)
 
 	if err := rg.publisher.PublishEmail(ctx, emailMsg); err != nil {
 		slog.Error("Failed to publish email", "user_id", user.ID, "error", err)
 		// Mark as failed but don't return error - recommendation is still saved
-		_ = models.MarkRecommendationAsFailed(rg.db, recommendation.ID)
-		return fmt.Errorf("failed to publish email: %w", err)
+		_ = rg.store.MarkFailed(recommendation.ID)
+		rg.progress.Report(ProgressEvent{
+			Type:       EventEmailFailed,
+			UserID:     user.ID,
+			MovieID:    movieID,
+			MovieTitle: recommendedMovie.Title,
+			Error:      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to publish email: %w", err)
 	}
 
+	rg.progress.Report(ProgressEvent{
+		Type:       EventEmailPublished,
+		UserID:     user.ID,
+		MovieID:    movieID,
+		MovieTitle: recommendedMovie.Title,
+	})
+
 	// Mark as sent
-	if err := models.MarkRecommendationAsSent(rg.db, recommendation.ID); err != nil {
+	if err := rg.store.MarkSent(recommendation.ID); err != nil {
 		slog.Warn("Failed to mark recommendation as sent", "recommendation_id", recommendation.ID, "error", err)
 	}
 
@@ -229,38 +283,192 @@ func (rg *RecommendationGenerator) GenerateForUser(ctx context.Context, user *au
 		"recommendation_id", recommendation.ID,
 		"email", user.Email)
 
-	return nil
+	return &recommendation, nil
 }
 
-func (rg *RecommendationGenerator) GenerateForAllUsers(ctx context.Context) error {
-	slog.Info("Starting recommendation generation for all users")
+// refreshBookings passes the booking history of every user to the
+// recommender, if it learns from them. It runs before any user is processed,
+// so users that are skipped later still count and cancelled bookings are
+// counted out before anyone gets a recommendation. The reservations it loads
+// are kept in the run for generating the users' recommendations.
+func (rg *RecommendationGenerator) refreshBookings(ctx context.Context, run *GenerationRun, users []auth.User) {
+	if rg.observer == nil {
+		return
+	}
+
+	slog.Info("Refreshing booking histories", "count", len(users))
+
+	tasks := make(chan uuid.UUID)
+	var wg sync.WaitGroup
+
+	for range rg.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range tasks {
+				rg.observeHistory(ctx, run, userID)
+			}
+		}()
+	}
+
+dispatch:
+	for _, user := range users {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case tasks <- user.ID:
+		}
+	}
+	close(tasks)
+	wg.Wait()
+}
+
+// observeHistory passes the movies a user booked to the recommender. A history
+// that could not be loaded completely is left out, as it would count the
+// missing bookings as cancelled. Failures only cost the recommender some data,
+// so they are logged and otherwise ignored.
+func (rg *RecommendationGenerator) observeHistory(ctx context.Context, run *GenerationRun, userID uuid.UUID) {
+	reservations, err := rg.nakupClient.GetUserReservations(userID)
+	if err != nil {
+		slog.Warn("Failed to fetch reservations for booking history", "user_id", userID, "error", err)
+		return
+	}
+	run.reservations.Store(userID, reservations)
+
+	movieIDs := make([]uuid.UUID, 0, len(reservations))
+	for _, reservation := range reservations {
+		timeSlot, err := run.Spored.GetTimeSlot(reservation.TimeSlotID)
+		if err != nil {
+			slog.Warn("Failed to fetch timeslot for booking history", "user_id", userID, "timeslot_id", reservation.TimeSlotID, "error", err)
+			return
+		}
+		movieIDs = append(movieIDs, timeSlot.MovieID)
+	}
+
+	if err := rg.observer.ObserveHistory(ctx, userID, movieIDs); err != nil {
+		slog.Warn("Failed to record booking history", "user_id", userID, "error", err)
+	}
+}
+
+func (rg *RecommendationGenerator) GenerateForAllUsers(ctx context.Context) (*GenerationSummary, error) {
+	slog.Info("Starting recommendation generation for all users", "workers", rg.workers, "dry_run", rg.dryRun)
 
 	users, err := rg.authClient.GetActiveUsers()
 	if err != nil {
-		return fmt.Errorf("failed to fetch active users: %w", err)
+		return nil, fmt.Errorf("failed to fetch active users: %w", err)
 	}
 
 	slog.Info("Fetched active users", "count", len(users))
 
-	successCount := 0
-	failureCount := 0
+	run, err := rg.NewGenerationRun()
+	if err != nil {
+		return nil, err
+	}
 
-	for i, user := range users {
-		slog.Info("Processing user", "index", i+1, "total", len(users), "user_id", user.ID, "email", user.Email)
+	summary := &GenerationSummary{
+		TotalUsers:  len(users),
+		SkipReasons: make(map[string]int),
+		Tiers:       make(map[string]int),
+		Errors:      []UserError{},
+		Catalog:     run.Catalog,
+	}
 
-		if err := rg.GenerateForUser(ctx, &user); err != nil {
-			slog.Error("Failed to generate recommendation for user", "user_id", user.ID, "error", err)
-			failureCount++
-			continue
-		}
+	if len(run.Catalog.Movies) == 0 {
+		return summary, fmt.Errorf("no upcoming movies available")
+	}
 
-		successCount++
+	rg.refreshBookings(ctx, run, users)
+
+	type userTask struct {
+		index int
+		user  auth.User
+	}
+
+	tasks := make(chan userTask)
+	var wg sync.WaitGroup
+
+	for range rg.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				rg.processUser(ctx, run, task.index, len(users), &task.user, summary)
+			}
+		}()
+	}
+
+	// Stop handing out users once the context is done, workers finish the
+	// user they are on and exit when the channel is closed.
+dispatch:
+	for i, user := range users {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case tasks <- userTask{index: i + 1, user: user}:
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	summary.SporedCache = run.Spored.Stats()
+
+	if ctx.Err() != nil {
+		slog.Warn("Recommendation generation stopped early",
+			"total_users", summary.TotalUsers,
+			"success", summary.SuccessCount,
+			"failure", summary.FailureCount,
+			"skipped", summary.SkippedCount,
+			"cause", context.Cause(ctx))
+		return summary, ctx.Err()
 	}
 
 	slog.Info("Recommendation generation completed",
-		"total_users", len(users),
-		"success", successCount,
-		"failure", failureCount)
+		"total_users", summary.TotalUsers,
+		"success", summary.SuccessCount,
+		"failure", summary.FailureCount,
+		"skipped", summary.SkippedCount,
+		"spored_cache", summary.SporedCache)
 
-	return nil
+	return summary, nil
+}
+
+func (rg *RecommendationGenerator) processUser(ctx context.Context, run *GenerationRun, index, total int, user *auth.User, summary *GenerationSummary) {
+	slog.Info("Processing user", "index", index, "total", total, "user_id", user.ID, "email", user.Email)
+	rg.progress.Report(ProgressEvent{
+		Type:       EventUserStarted,
+		UserID:     user.ID,
+		UserIndex:  index,
+		TotalUsers: total,
+	})
+
+	recommendation, err := rg.GenerateForUser(ctx, run, user)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Interrupted mid-user, not a failure of the user itself
+			return
+		}
+
+		var skipErr *SkipError
+		if errors.As(err, &skipErr) {
+			slog.Info("Skipped user", "user_id", user.ID, "reason", skipErr.Reason)
+			summary.recordSkip(skipErr.Reason)
+			rg.progress.Report(ProgressEvent{
+				Type:   EventUserSkipped,
+				UserID: user.ID,
+				Error:  skipErr.Reason,
+			})
+			return
+		}
+
+		slog.Error("Failed to generate recommendation for user", "user_id", user.ID, "error", err)
+		summary.recordFailure(user.ID, err)
+		rg.progress.Report(ProgressEvent{
+			Type:   EventUserFailed,
+			UserID: user.ID,
+			Error:  err.Error(),
+		})
+		return
+	}
+
+	summary.recordSuccess(recommendation.Tier)
 }
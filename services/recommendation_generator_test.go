@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/auth"
+	"github.com/PRPO-skupina-02/predlogi/clients/nakup"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsers []auth.User
+
+func (f fakeUsers) GetActiveUsers() ([]auth.User, error) {
+	return f, nil
+}
+
+func (f fakeUsers) GetUser(userID uuid.UUID) (*auth.User, error) {
+	for i := range f {
+		if f[i].ID == userID {
+			return &f[i], nil
+		}
+	}
+	return nil, auth.ErrUserNotFound
+}
+
+type fakeReservations struct {
+	mu     sync.Mutex
+	byUser map[uuid.UUID][]nakup.Reservation
+	calls  int
+}
+
+func (f *fakeReservations) GetUserReservations(userID uuid.UUID) ([]nakup.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.byUser[userID], nil
+}
+
+type fakeSchedule []spored.TimeSlot
+
+func (f fakeSchedule) GetTimeSlot(timeSlotID uuid.UUID) (*spored.TimeSlot, error) {
+	for i := range f {
+		if f[i].ID == timeSlotID {
+			return &f[i], nil
+		}
+	}
+	return nil, assert.AnError
+}
+
+func (f fakeSchedule) GetMovie(movieID uuid.UUID) (*spored.Movie, error) {
+	for i := range f {
+		if f[i].MovieID == movieID {
+			return &f[i].Movie, nil
+		}
+	}
+	return nil, assert.AnError
+}
+
+func (f fakeSchedule) GetUpcomingTimeSlots(startDate, endDate time.Time) ([]spored.TimeSlot, error) {
+	timeSlots := []spored.TimeSlot{}
+	for _, timeSlot := range f {
+		if timeSlot.StartTime.After(startDate) && timeSlot.StartTime.Before(endDate) {
+			timeSlots = append(timeSlots, timeSlot)
+		}
+	}
+	return timeSlots, nil
+}
+
+type fakeStore struct {
+	mu              sync.Mutex
+	preferences     map[uuid.UUID]models.RecommendationPreferences
+	recommendations []models.Recommendation
+}
+
+func (f *fakeStore) GetPreferences(userID uuid.UUID) (models.RecommendationPreferences, error) {
+	if preferences, ok := f.preferences[userID]; ok {
+		return preferences, nil
+	}
+	return models.DefaultRecommendationPreferences(userID), nil
+}
+
+func (f *fakeStore) CountSentSince(uuid.UUID, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeStore) GetRecommendedMovieIDsSince(uuid.UUID, time.Time) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) GetFeedback(uuid.UUID) ([]models.RecommendationFeedback, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) CreateRecommendation(recommendation *models.Recommendation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recommendations = append(f.recommendations, *recommendation)
+	return nil
+}
+
+func (f *fakeStore) MarkSent(uuid.UUID) error {
+	return nil
+}
+
+func (f *fakeStore) MarkFailed(uuid.UUID) error {
+	return nil
+}
+
+type generatorFixture struct {
+	generator    *RecommendationGenerator
+	store        *fakeStore
+	reservations *fakeReservations
+	recommender  *stubRecommender
+	users        fakeUsers
+	watched      spored.TimeSlot
+	upcoming     spored.TimeSlot
+}
+
+// newGeneratorFixture sets up two customers who both watched one movie, with
+// that movie and another one showing soon.
+func newGeneratorFixture(t *testing.T) *generatorFixture {
+	t.Setenv("PUBLIC_BASE_URL", "https://predlogi.example.com")
+	t.Setenv("RECOMMENDATION_TOKEN_SECRET", "secret")
+
+	now := time.Now()
+	seen := spored.Movie{ID: uuid.New(), Title: "Seen"}
+	unseen := spored.Movie{ID: uuid.New(), Title: "Unseen"}
+
+	watched := spored.TimeSlot{ID: uuid.New(), StartTime: now.AddDate(0, 0, -7), MovieID: seen.ID, Movie: seen}
+	rerun := spored.TimeSlot{ID: uuid.New(), StartTime: now.AddDate(0, 0, 1), MovieID: seen.ID, Movie: seen}
+	upcoming := spored.TimeSlot{ID: uuid.New(), StartTime: now.AddDate(0, 0, 2), MovieID: unseen.ID, Movie: unseen}
+
+	users := fakeUsers{
+		{ID: uuid.New(), Email: "ana@example.com", Role: auth.RoleCustomer, Active: true},
+		{ID: uuid.New(), Email: "bor@example.com", Role: auth.RoleCustomer, Active: true},
+	}
+	reservations := &fakeReservations{byUser: make(map[uuid.UUID][]nakup.Reservation)}
+	for _, user := range users {
+		reservations.byUser[user.ID] = []nakup.Reservation{{ID: uuid.New(), UserID: user.ID, TimeSlotID: watched.ID}}
+	}
+
+	recommender := &stubRecommender{result: &RecommendationResult{
+		Recommendations: []RecommendationResponse{{MovieID: unseen.ID.String(), Reason: "Because", ConfidenceScore: 0.7}},
+	}}
+
+	generator, err := NewRecommendationGenerator(nil, users, reservations, fakeSchedule{watched, rerun, upcoming}, NewFallbackRecommender(recommender), "")
+	require.NoError(t, err)
+
+	store := &fakeStore{preferences: make(map[uuid.UUID]models.RecommendationPreferences)}
+	generator.store = store
+	generator.SetDryRun(true)
+
+	return &generatorFixture{
+		generator:    generator,
+		store:        store,
+		reservations: reservations,
+		recommender:  recommender,
+		users:        users,
+		watched:      watched,
+		upcoming:     upcoming,
+	}
+}
+
+func TestGenerateForUser(t *testing.T) {
+	f := newGeneratorFixture(t)
+	assert.Nil(t, f.generator.observer, "no tier observes booking histories")
+
+	run, err := f.generator.NewGenerationRun()
+	require.NoError(t, err)
+
+	recommendation, err := f.generator.GenerateForUser(context.Background(), run, &f.users[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, f.upcoming.MovieID, recommendation.MovieID)
+	assert.Equal(t, models.StatusDryRun, recommendation.Status)
+	assert.Equal(t, TierPrimary, recommendation.Tier)
+	assert.Equal(t, "Perfect Movie for You: Unseen", recommendation.EmailSubject)
+	require.Len(t, f.store.recommendations, 1)
+	assert.Contains(t, recommendation.GenerationContext, `"UnsubscribeURL":""`)
+
+	// The watched movie is in the history and not offered again
+	require.Len(t, f.recommender.req.UserHistory, 1)
+	assert.Equal(t, "Seen", f.recommender.req.UserHistory[0].Title)
+	require.Len(t, f.recommender.req.UpcomingMovies, 1)
+	assert.Equal(t, "Unseen", f.recommender.req.UpcomingMovies[0].Title)
+}
+
+func TestGenerateForAllUsers(t *testing.T) {
+	f := newGeneratorFixture(t)
+
+	optedOut := models.DefaultRecommendationPreferences(f.users[1].ID)
+	optedOut.OptedIn = false
+	f.store.preferences[optedOut.UserID] = optedOut
+
+	summary, err := f.generator.GenerateForAllUsers(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, summary.TotalUsers)
+	assert.Equal(t, 1, summary.SuccessCount)
+	assert.Equal(t, 1, summary.SkippedCount)
+	assert.Equal(t, map[string]int{SkipOptedOut: 1}, summary.SkipReasons)
+	assert.Equal(t, map[string]int{TierPrimary: 1}, summary.Tiers)
+	require.Len(t, f.store.recommendations, 1)
+	assert.Equal(t, f.users[0].ID, f.store.recommendations[0].UserID)
+}
+
+type observingRecommender struct {
+	stubRecommender
+	mu       sync.Mutex
+	observed map[uuid.UUID][]uuid.UUID
+}
+
+func (r *observingRecommender) ObserveHistory(ctx context.Context, userID uuid.UUID, movieIDs []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observed[userID] = movieIDs
+	return nil
+}
+
+func TestGenerateForAllUsersObservesSkippedUsers(t *testing.T) {
+	f := newGeneratorFixture(t)
+
+	recommender := &observingRecommender{stubRecommender: *f.recommender, observed: make(map[uuid.UUID][]uuid.UUID)}
+	f.generator.recommender = recommender
+	f.generator.observer = recommender
+
+	optedOut := models.DefaultRecommendationPreferences(f.users[1].ID)
+	optedOut.OptedIn = false
+	f.store.preferences[optedOut.UserID] = optedOut
+
+	_, err := f.generator.GenerateForAllUsers(context.Background())
+	require.NoError(t, err)
+
+	// Generation reuses the reservations loaded for the recommender
+	assert.Equal(t, len(f.users), f.reservations.calls)
+	assert.Equal(t, map[uuid.UUID][]uuid.UUID{
+		f.users[0].ID: {f.watched.MovieID},
+		f.users[1].ID: {f.watched.MovieID},
+	}, recommender.observed)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// Recommender picks movies for a user out of the candidates in a request.
+type Recommender interface {
+	// Recommend returns the candidates it recommends, best first. The
+	// result is returned even on error when there is a trace to show.
+	Recommend(ctx context.Context, req RecommendationRequest) (*RecommendationResult, error)
+}
+
+// RecommendationResult holds ranked recommendations and, for recommenders
+// that have one, a trace of how they were produced. Tier is set by
+// FallbackRecommender.
+type RecommendationResult struct {
+	Recommendations []RecommendationResponse
+	Trace           RecommendationTrace
+	Tier            string
+}
+
+// Best returns the top recommendation, or nil if there is none.
+func (r *RecommendationResult) Best() *RecommendationResponse {
+	if r == nil || len(r.Recommendations) == 0 {
+		return nil
+	}
+	return &r.Recommendations[0]
+}
+
+// RecommendationTrace records the exchange with a model behind a
+// recommendation, for debugging.
+type RecommendationTrace struct {
+	Prompt    string
+	RawOutput string
+}
+
+// RecommendationStrategy selects the Recommender used by the job.
+type RecommendationStrategy string
+
+const (
+	StrategyOpenAI        RecommendationStrategy = "openai"
+	StrategyContent       RecommendationStrategy = "content"
+	StrategyCollaborative RecommendationStrategy = "collaborative"
+	StrategyNone          RecommendationStrategy = "none" // Only valid as a fallback
+)
+
+// NewRecommenderFromEnv builds the Recommender selected by
+// RECOMMENDATION_STRATEGY, backed by a fallback chain. An OpenAI primary
+// falls back to OPENROUTER_FALLBACK_MODEL if set, and any primary falls back
+// to the local recommender in RECOMMENDATION_FALLBACK_STRATEGY.
+func NewRecommenderFromEnv(db *gorm.DB) (Recommender, error) {
+	strategy := RecommendationStrategy(os.Getenv("RECOMMENDATION_STRATEGY"))
+	if strategy == "" {
+		strategy = StrategyOpenAI
+	}
+
+	fallback := RecommendationStrategy(os.Getenv("RECOMMENDATION_FALLBACK_STRATEGY"))
+	if fallback == "" {
+		fallback = StrategyContent
+	}
+	if fallback != StrategyNone && fallback != StrategyContent && fallback != StrategyCollaborative {
+		return nil, fmt.Errorf("invalid RECOMMENDATION_FALLBACK_STRATEGY %q", fallback)
+	}
+
+	primary, err := newRecommender(strategy, db)
+	if err != nil {
+		return nil, err
+	}
+	chain := NewFallbackRecommender(primary)
+
+	if model := os.Getenv("OPENROUTER_FALLBACK_MODEL"); model != "" && strategy == StrategyOpenAI {
+		secondary, err := NewOpenAIServiceWithModel(model)
+		if err != nil {
+			return nil, err
+		}
+		chain.Add(TierSecondary, secondary)
+	}
+
+	if fallback != StrategyNone && fallback != strategy {
+		local, err := newRecommender(fallback, db)
+		if err != nil {
+			return nil, err
+		}
+		chain.Add(TierLocal, local)
+	}
+
+	return chain, nil
+}
+
+func newRecommender(strategy RecommendationStrategy, db *gorm.DB) (Recommender, error) {
+	switch strategy {
+	case StrategyOpenAI:
+		service, err := NewOpenAIService()
+		if err != nil {
+			return nil, err
+		}
+		return service, nil
+	case StrategyContent:
+		return NewContentRecommender(), nil
+	case StrategyCollaborative:
+		return NewCollaborativeRecommender(db), nil
+	default:
+		return nil, fmt.Errorf("invalid RECOMMENDATION_STRATEGY %q", strategy)
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendationResultBest(t *testing.T) {
+	var empty *RecommendationResult
+	assert.Nil(t, empty.Best())
+	assert.Nil(t, (&RecommendationResult{}).Best())
+
+	result := &RecommendationResult{
+		Recommendations: []RecommendationResponse{{MovieID: "first"}, {MovieID: "second"}},
+	}
+	assert.Equal(t, "first", result.Best().MovieID)
+}
+
+func TestNewRecommenderFromEnvRejectsUnknownStrategy(t *testing.T) {
+	t.Setenv("RECOMMENDATION_STRATEGY", "astrology")
+
+	_, err := NewRecommenderFromEnv(nil)
+	assert.ErrorContains(t, err, "RECOMMENDATION_STRATEGY")
+}
+
+func TestNewRecommenderFromEnvRejectsUnknownFallback(t *testing.T) {
+	t.Setenv("RECOMMENDATION_STRATEGY", string(StrategyContent))
+	t.Setenv("RECOMMENDATION_FALLBACK_STRATEGY", "coin_flip")
+
+	_, err := NewRecommenderFromEnv(nil)
+	assert.ErrorContains(t, err, "RECOMMENDATION_FALLBACK_STRATEGY")
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Placeholders supported in reservation URL templates.
+const (
+	PlaceholderMovieID          = "{movie_id}"
+	PlaceholderTimeSlotID       = "{timeslot_id}"
+	PlaceholderRecommendationID = "{recommendation_id}"
+	PlaceholderCampaign         = "{campaign}"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ReservationLink holds the values substituted into a reservation URL
+// template. TimeSlotID may be nil when no screening is known, in which case
+// {timeslot_id} expands to an empty string.
+type ReservationLink struct {
+	MovieID          uuid.UUID
+	TimeSlotID       uuid.UUID
+	RecommendationID uuid.UUID
+	Campaign         string
+}
+
+// ReservationLinkBuilder builds deep links into the cinema frontend from a
+// URL template such as https://cinema.example.com/reserve?movie={movie_id}.
+type ReservationLinkBuilder struct {
+	template string
+}
+
+// NewReservationLinkBuilder validates template and returns a builder for it.
+// The template must use known placeholders only, reference the movie, and
+// expand to an absolute http(s) URL. The movie is required because a
+// recommended movie may have no screening left by the time the link is built.
+func NewReservationLinkBuilder(template string) (*ReservationLinkBuilder, error) {
+	for _, placeholder := range placeholderPattern.FindAllString(template, -1) {
+		switch placeholder {
+		case PlaceholderMovieID, PlaceholderTimeSlotID, PlaceholderRecommendationID, PlaceholderCampaign:
+		default:
+			return nil, fmt.Errorf("unknown placeholder %s in reservation URL template", placeholder)
+		}
+	}
+
+	if strings.ContainsAny(placeholderPattern.ReplaceAllString(template, ""), "{}") {
+		return nil, fmt.Errorf("unbalanced braces in reservation URL template")
+	}
+
+	if !strings.Contains(template, PlaceholderMovieID) {
+		return nil, fmt.Errorf("reservation URL template must contain %s", PlaceholderMovieID)
+	}
+
+	builder := &ReservationLinkBuilder{template: template}
+
+	sample, err := url.Parse(builder.Build(ReservationLink{
+		MovieID:          uuid.New(),
+		TimeSlotID:       uuid.New(),
+		RecommendationID: uuid.New(),
+		Campaign:         "campaign",
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid reservation URL template: %w", err)
+	}
+	if (sample.Scheme != "http" && sample.Scheme != "https") || sample.Host == "" {
+		return nil, fmt.Errorf("reservation URL template must be an absolute http(s) URL")
+	}
+
+	return builder, nil
+}
+
+// Build expands the template for link. The campaign is escaped for where it
+// appears, as a path segment before the query and as a query value after it.
+func (b *ReservationLinkBuilder) Build(link ReservationLink) string {
+	path, query, hasQuery := strings.Cut(b.template, "?")
+
+	expanded := b.replacer(link, url.PathEscape).Replace(path)
+	if hasQuery {
+		expanded += "?" + b.replacer(link, url.QueryEscape).Replace(query)
+	}
+	return expanded
+}
+
+func (b *ReservationLinkBuilder) replacer(link ReservationLink, escape func(string) string) *strings.Replacer {
+	timeSlotID := ""
+	if link.TimeSlotID != uuid.Nil {
+		timeSlotID = link.TimeSlotID.String()
+	}
+
+	return strings.NewReplacer(
+		PlaceholderMovieID, link.MovieID.String(),
+		PlaceholderTimeSlotID, timeSlotID,
+		PlaceholderRecommendationID, link.RecommendationID.String(),
+		PlaceholderCampaign, escape(link.Campaign),
+	)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservationLinkBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		valid    bool
+	}{
+		{"Movie", "https://cinema.example.com/reserve?movie={movie_id}", true},
+		{"Movie and timeslot path", "https://staging.cinema.example.com/movies/{movie_id}/reserve?slot={timeslot_id}&c={campaign}", true},
+		{"All placeholders", "http://localhost:3000/r?m={movie_id}&t={timeslot_id}&r={recommendation_id}&c={campaign}", true},
+		{"Unknown placeholder", "https://cinema.example.com/reserve?movie={movie}", false},
+		{"Unbalanced braces", "https://cinema.example.com/reserve?movie={movie_id", false},
+		{"Timeslot only", "https://cinema.example.com/timeslots/{timeslot_id}/reserve", false},
+		{"No movie", "https://cinema.example.com/reserve?rec={recommendation_id}", false},
+		{"Relative", "/reserve?movie={movie_id}", false},
+		{"Not http", "ftp://cinema.example.com/{movie_id}", false},
+		{"Empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReservationLinkBuilder(tt.template)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestReservationLinkBuilderBuild(t *testing.T) {
+	builder, err := NewReservationLinkBuilder("https://cinema.example.com/reserve?movie={movie_id}&slot={timeslot_id}&rec={recommendation_id}&c={campaign}")
+	require.NoError(t, err)
+
+	movieID := uuid.New()
+	recommendationID := uuid.New()
+
+	link := builder.Build(ReservationLink{
+		MovieID:          movieID,
+		RecommendationID: recommendationID,
+		Campaign:         "spring sale",
+	})
+
+	assert.Equal(t, "https://cinema.example.com/reserve?movie="+movieID.String()+"&slot=&rec="+recommendationID.String()+"&c=spring+sale", link)
+}
+
+func TestReservationLinkBuilderBuildPath(t *testing.T) {
+	builder, err := NewReservationLinkBuilder("https://cinema.example.com/{campaign}/movies/{movie_id}?c={campaign}")
+	require.NoError(t, err)
+
+	movieID := uuid.New()
+
+	link := builder.Build(ReservationLink{
+		MovieID:  movieID,
+		Campaign: "spring sale/2026",
+	})
+
+	assert.Equal(t, "https://cinema.example.com/spring%20sale%2F2026/movies/"+movieID.String()+"?c=spring+sale%2F2026", link)
+}
@@ -0,0 +1,19 @@
+package services
+
+// Reasons for deliberately not sending a recommendation to a user.
+const (
+	SkipNoEligibleMovies = "no_eligible_movies"
+	SkipFrequencyCap     = "frequency_cap"
+	SkipOptedOut         = "opted_out"
+	SkipUserFrequencyCap = "user_frequency_cap"
+)
+
+// SkipError reports that a user was deliberately not sent a recommendation.
+// Skipped users are not counted as failures.
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	return "user skipped: " + e.Reason
+}
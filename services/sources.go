@@ -0,0 +1,77 @@
+package services
+
+import (
+	"time"
+
+	"github.com/PRPO-skupina-02/predlogi/clients/auth"
+	"github.com/PRPO-skupina-02/predlogi/clients/nakup"
+	"github.com/PRPO-skupina-02/predlogi/clients/spored"
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The generator depends on the other microservices only through these
+// interfaces, so it can be driven by fakes in tests. The clients in the
+// clients package implement them.
+
+// UserSource looks up the users recommendations are generated for.
+type UserSource interface {
+	GetActiveUsers() ([]auth.User, error)
+	GetUser(userID uuid.UUID) (*auth.User, error)
+}
+
+// ReservationSource returns the reservations of a user.
+type ReservationSource interface {
+	GetUserReservations(userID uuid.UUID) ([]nakup.Reservation, error)
+}
+
+// ScheduleSource returns the cinema schedule and the movies in it.
+type ScheduleSource interface {
+	spored.Lookup
+	GetUpcomingTimeSlots(startDate, endDate time.Time) ([]spored.TimeSlot, error)
+}
+
+// recommendationStore is the part of the database the generator uses.
+type recommendationStore interface {
+	GetPreferences(userID uuid.UUID) (models.RecommendationPreferences, error)
+	CountSentSince(userID uuid.UUID, since time.Time) (int64, error)
+	GetRecommendedMovieIDsSince(userID uuid.UUID, since time.Time) ([]uuid.UUID, error)
+	GetFeedback(userID uuid.UUID) ([]models.RecommendationFeedback, error)
+	CreateRecommendation(recommendation *models.Recommendation) error
+	MarkSent(id uuid.UUID) error
+	MarkFailed(id uuid.UUID) error
+}
+
+// gormStore implements recommendationStore with the models package.
+type gormStore struct {
+	db *gorm.DB
+}
+
+func (s gormStore) GetPreferences(userID uuid.UUID) (models.RecommendationPreferences, error) {
+	return models.GetRecommendationPreferences(s.db, userID)
+}
+
+func (s gormStore) CountSentSince(userID uuid.UUID, since time.Time) (int64, error) {
+	return models.CountRecommendationsSentSince(s.db, userID, since)
+}
+
+func (s gormStore) GetRecommendedMovieIDsSince(userID uuid.UUID, since time.Time) ([]uuid.UUID, error) {
+	return models.GetRecommendedMovieIDsSince(s.db, userID, since)
+}
+
+func (s gormStore) GetFeedback(userID uuid.UUID) ([]models.RecommendationFeedback, error) {
+	return models.GetFeedbackByUser(s.db, userID)
+}
+
+func (s gormStore) CreateRecommendation(recommendation *models.Recommendation) error {
+	return recommendation.Create(s.db)
+}
+
+func (s gormStore) MarkSent(id uuid.UUID) error {
+	return models.MarkRecommendationAsSent(s.db, id)
+}
+
+func (s gormStore) MarkFailed(id uuid.UUID) error {
+	return models.MarkRecommendationAsFailed(s.db, id)
+}
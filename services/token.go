@@ -0,0 +1,79 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TokenPurpose binds a token to the action it was issued for, so a token
+// issued for one link cannot be replayed against another.
+type TokenPurpose string
+
+const (
+	TokenOpen  TokenPurpose = "open"
+	TokenClick TokenPurpose = "click"
+
+	// TokenUnsubscribe tokens carry a user ID instead of a recommendation ID
+	TokenUnsubscribe TokenPurpose = "unsubscribe"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenSigner issues and verifies HMAC-signed tokens that carry a single ID.
+type TokenSigner struct {
+	secret []byte
+}
+
+func NewTokenSigner(secret string) (*TokenSigner, error) {
+	if secret == "" {
+		return nil, errors.New("token secret must not be empty")
+	}
+	return &TokenSigner{secret: []byte(secret)}, nil
+}
+
+func (s *TokenSigner) Sign(purpose TokenPurpose, id uuid.UUID) string {
+	payload := id[:]
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(purpose, payload))
+}
+
+func (s *TokenSigner) Verify(purpose TokenPurpose, token string) (uuid.UUID, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, s.mac(purpose, payload)) {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	id, err := uuid.FromBytes(payload)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+
+	return id, nil
+}
+
+func (s *TokenSigner) mac(purpose TokenPurpose, payload []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(purpose))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/PRPO-skupina-02/predlogi/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSigner(t *testing.T) {
+	signer, err := NewTokenSigner("secret")
+	require.NoError(t, err)
+
+	id := uuid.New()
+	token := signer.Sign(TokenOpen, id)
+
+	verified, err := signer.Verify(TokenOpen, token)
+	assert.NoError(t, err)
+	assert.Equal(t, id, verified)
+
+	other, err := NewTokenSigner("other-secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		signer  *TokenSigner
+		purpose TokenPurpose
+		token   string
+	}{
+		{"Wrong purpose", signer, TokenPurpose("other"), token},
+		{"Wrong secret", other, TokenOpen, token},
+		{"Forged ID", signer, TokenOpen, signer.Sign(TokenOpen, uuid.New())[:22] + token[22:]},
+		{"Malformed", signer, TokenOpen, "not-a-token"},
+		{"Empty", signer, TokenOpen, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.signer.Verify(tt.purpose, tt.token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestFeedbackTokensAreBoundToType(t *testing.T) {
+	signer, err := NewTokenSigner("secret")
+	require.NoError(t, err)
+
+	id := uuid.New()
+	token := signer.Sign(FeedbackTokenPurpose(models.FeedbackLike), id)
+
+	_, err = signer.Verify(FeedbackTokenPurpose(models.FeedbackLike), token)
+	assert.NoError(t, err)
+
+	_, err = signer.Verify(FeedbackTokenPurpose(models.FeedbackDislike), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}